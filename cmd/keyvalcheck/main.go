@@ -0,0 +1,15 @@
+// Command keyvalcheck reports misuse of the key/value pairs passed to
+// package github.com/jjeffery/errors.
+//
+// It can be run directly, or using go vet:
+//  go vet -vettool=$(which keyvalcheck) ./...
+package main
+
+import (
+	"github.com/jjeffery/errors/keyvalcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(keyvalcheck.Analyzer)
+}
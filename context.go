@@ -2,6 +2,8 @@ package errors
 
 import (
 	"bytes"
+	"encoding"
+	"fmt"
 	"strings"
 
 	"github.com/jjeffery/kv"
@@ -10,17 +12,25 @@ import (
 // A context implements the public Context interface.
 type context struct {
 	keyvals []interface{}
+	redact  *RedactPolicy
 }
 
-// New creates a new context.
+// New creates a new error.
 func (ctx context) New(msg string) Error {
-	return ctx.newError(msg)
+	return ctx.newError(msg, callers())
 }
 
+// Wrap wraps an existing error.
 func (ctx context) Wrap(err error, msg ...string) Error {
 	if err == nil {
 		return nil
 	}
+	return ctx.wrap(err, msg, callers())
+}
+
+// wrap wraps err, which must not be nil, with the message and
+// stack trace.
+func (ctx context) wrap(err error, msg []string, st *stack) Error {
 	// strip out any empty strings in the msg slice
 	{
 		v := make([]string, 0, len(msg))
@@ -34,20 +44,52 @@ func (ctx context) Wrap(err error, msg ...string) Error {
 	if len(msg) == 0 {
 		// A wrap without a message just attaches the options
 		// to the error.
-		return ctx.attachError(err)
+		return ctx.attachError(err, st)
+	}
+	return ctx.wrapError(err, strings.Join(msg, ": "), st)
+}
+
+// Join returns an error that aggregates errs. Any nil errors
+// are discarded, and if there are no errors remaining, Join returns nil.
+func (ctx context) Join(errs ...error) Error {
+	return ctx.join(errs, callers())
+}
+
+// join aggregates the non-nil errors in errs, and records the stack trace.
+func (ctx context) join(errs []error, st *stack) Error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &joinT{
+		ctx:   ctx.clone(),
+		errs:  nonNil,
+		stack: st,
 	}
-	return ctx.wrapError(err, strings.Join(msg, ": "))
 }
 
 // Keyvals implements the keyvalser interface.
 func (ctx context) Keyvals() []interface{} {
-	return ctx.keyvals
+	return ctx.appendKeyvals(nil)
 }
 
 func (ctx context) With(keyvals ...interface{}) Context {
 	return ctx.withKeyvals(keyvals)
 }
 
+// WithRedactPolicy returns a context with a redaction policy
+// that applies to errors created from the context.
+func (ctx context) WithRedactPolicy(policy *RedactPolicy) Context {
+	ctx = ctx.clone()
+	ctx.redact = policy
+	return ctx
+}
+
 // safeSlice returns a slice whose capacity is the same as its length.
 // This slice is safe for concurrent operations because any attempt to
 // append to the slice will result in a new underlying array being allocated.
@@ -62,6 +104,7 @@ func safeSlice(keyvals []interface{}) []interface{} {
 func (ctx context) clone() context {
 	return context{
 		keyvals: safeSlice(ctx.keyvals),
+		redact:  ctx.redact,
 	}
 }
 
@@ -71,32 +114,44 @@ func (ctx context) withKeyvals(keyvals []interface{}) context {
 	return ctx
 }
 
-func (ctx context) newError(msg string) *errorT {
+func (ctx context) newError(msg string, st *stack) *errorT {
 	return &errorT{
-		ctx: ctx.clone(),
-		msg: msg,
+		ctx:   ctx.clone(),
+		msg:   msg,
+		stack: st,
 	}
 }
 
-func (ctx context) wrapError(cause error, msg string) *causeT {
+func (ctx context) wrapError(cause error, msg string, st *stack) *causeT {
 	return &causeT{
 		errorT: &errorT{
-			msg: msg,
-			ctx: ctx.clone(),
+			msg:   msg,
+			ctx:   ctx.clone(),
+			stack: st,
 		},
 		cause: cause,
 	}
 }
 
-func (ctx context) attachError(cause error) Error {
+func (ctx context) attachError(cause error, st *stack) Error {
 	return &attachT{
 		ctx:   ctx.clone(),
 		cause: cause,
+		stack: st,
 	}
 }
 
+// appendKeyvals appends the context's key/value pairs to keyvals,
+// with any values redacted according to the redaction policies,
+// and any lazy values evaluated.
 func (ctx context) appendKeyvals(keyvals []interface{}) []interface{} {
-	return append(keyvals, ctx.keyvals...)
+	for i, v := range ctx.keyvals {
+		if i%2 == 1 {
+			v = ctx.redactValue(ctx.keyvals[i-1], v)
+		}
+		keyvals = append(keyvals, v)
+	}
+	return keyvals
 }
 
 // writeToBuf writes the context's key/value pairs to a buffer.
@@ -104,10 +159,39 @@ func (ctx context) writeToBuf(buf *bytes.Buffer) {
 	if len(ctx.keyvals) == 0 {
 		return
 	}
+	keyvals := ctx.appendKeyvals(nil)
+	for i := 1; i < len(keyvals); i += 2 {
+		keyvals[i] = safeText(keyvals[i])
+	}
 	// kv.List.MarshalText does not return a non-nil error.
-	b, _ := kv.List(ctx.keyvals).MarshalText()
+	b, _ := kv.List(keyvals).MarshalText()
 	if buf.Len() > 0 {
 		buf.WriteRune(' ')
 	}
 	buf.Write(b)
 }
+
+// safeText returns the text of a value that implements the error or
+// fmt.Stringer interface, so that a panic in its Error or String method
+// is rendered as "PANIC" instead of crashing the program. Other values,
+// including text marshalers, are returned unchanged for package kv to
+// render.
+func safeText(value interface{}) (text interface{}) {
+	switch value.(type) {
+	case encoding.TextMarshaler:
+		// kv recovers from a panic in MarshalText
+		return value
+	case error, fmt.Stringer:
+	default:
+		return value
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			text = "PANIC"
+		}
+	}()
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	return value.(fmt.Stringer).String()
+}
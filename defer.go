@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"io"
+)
+
+// WrapPtr wraps the error pointed to by errp, if it is not nil. It is
+// intended to be called by a deferred function call in a function with a
+// named error result, so that every error returned by the function is
+// wrapped with the same message:
+//  func syncFiles(dir string) (err error) {
+//      defer errors.WrapPtr(&err, "cannot sync")
+//
+//      // ... every error returned is wrapped ...
+//  }
+//
+// If *errp is nil, it is left unchanged. The error records the stack trace
+// at the point the deferred call was made.
+func WrapPtr(errp *error, message ...string) {
+	if *errp != nil {
+		var ctx context
+		*errp = ctx.wrap(*errp, message, trimRuntime(callers()))
+	}
+}
+
+// WrapPtr wraps the error pointed to by errp, if it is not nil, with the
+// context's key/value pairs attached. See the WrapPtr function.
+func (ctx context) WrapPtr(errp *error, message ...string) {
+	if *errp != nil {
+		*errp = ctx.wrap(*errp, message, trimRuntime(callers()))
+	}
+}
+
+// Close closes closer, and if Close fails, combines the failure with the
+// error pointed to by errp. It is intended to be called by a deferred
+// function call in a function with a named error result:
+//  func writeFile(name string, data []byte) (err error) {
+//      f, err := os.Create(name)
+//      if err != nil {
+//          return err
+//      }
+//      defer errors.Close(&err, f)
+//
+//      // ... write to f ...
+//  }
+//
+// If *errp is nil, it is set to the error returned by Close. Otherwise both
+// errors are reported, using the same semantics as the Join function.
+func Close(errp *error, closer io.Closer) {
+	closeErr := closer.Close()
+	if closeErr == nil {
+		return
+	}
+	if *errp == nil {
+		*errp = closeErr
+		return
+	}
+	var ctx context
+	*errp = ctx.join([]error{*errp, closeErr}, trimRuntime(callers()))
+}
@@ -0,0 +1,73 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+type closer struct {
+	err    error
+	closed int
+}
+
+func (c *closer) Close() error {
+	c.closed++
+	return c.err
+}
+
+func TestWrapPtr(t *testing.T) {
+	sync := func(err error) (result error) {
+		defer WrapPtr(&result, "cannot sync")
+		return err
+	}
+	if err := sync(nil); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	err := sync(io.EOF)
+	if got, want := err.Error(), "cannot sync: EOF"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got, want := fmt.Sprintf("%n", err.(*causeT).StackTrace()[0]), "TestWrapPtr.func1"; got != want {
+		t.Errorf("want stack trace to start at %q, got %q", want, got)
+	}
+
+	syncContext := func(err error) (result error) {
+		errors := With("dir", "tmp")
+		defer errors.WrapPtr(&result, "cannot sync")
+		return err
+	}
+	if err := syncContext(nil); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if got, want := syncContext(io.EOF).Error(), "cannot sync dir=tmp: EOF"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	write := func(c *closer, err error) (result error) {
+		defer Close(&result, c)
+		return err
+	}
+
+	c := &closer{}
+	if err := write(c, nil); err != nil || c.closed != 1 {
+		t.Errorf("want nil and closed once, got %v and %d", err, c.closed)
+	}
+	if err := write(&closer{}, io.EOF); err != io.EOF {
+		t.Errorf("want %v, got %v", io.EOF, err)
+	}
+	closeErr := New("cannot flush")
+	if err := write(&closer{err: closeErr}, nil); err != closeErr {
+		t.Errorf("want %v, got %v", closeErr, err)
+	}
+
+	err := write(&closer{err: closeErr}, io.EOF)
+	if got, want := err.Error(), "EOF; cannot flush"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if !Is(err, io.EOF) || !Is(err, closeErr) {
+		t.Errorf("want both errors to match")
+	}
+}
@@ -93,6 +93,33 @@ function scope:
      return nil
  }
 
+Instead of wrapping the error at every return, a function with a named error
+result can wrap any error it returns using a deferred call to `WrapPtr`. The
+`Close` function is useful in the same way for reporting an error from a
+deferred call to a Close method without losing the error being returned:
+ func writeFile(name string, data []byte) (err error) {
+     defer errors.WrapPtr(&err, "cannot write file")
+
+     f, err := os.Create(name)
+     if err != nil {
+         return err
+     }
+     defer errors.Close(&err, f)
+
+     // ... and so on ...
+ }
+
+Key/value pairs that apply to a whole request, such as a request ID, can be
+carried by a context.Context using `NewContext`. The `FromContext` function
+returns an error context with those key/value pairs, together with any values
+obtained by the extractors passed to `RegisterExtractor`:
+ ctx = errors.NewContext(ctx, "request_id", requestID)
+
+ // ... later, in another function ...
+
+ errors := errors.FromContext(ctx)
+ return errors.Wrap(err, "cannot get document")
+
 The errors returned by `New` and `Wrap` provide a `With` method that enables
 a fluent-style of error handling:
  // create new error
@@ -110,6 +137,78 @@ a fluent-style of error handling:
  // file locked file=testrun line=101
  // retry failed attempt=3: file locked file=testrun line=101
 
+A message can refer to the value of a key/value pair by enclosing its key in
+braces. The placeholder is replaced with the value when the error is rendered,
+while the message template remains available for grouping similar errors:
+ err = errors.With("file", file).New("cannot open {file}")
+ fmt.Println(err)
+
+ // Output:
+ // cannot open testrun file=testrun
+
+A placeholder is only replaced if there is a key/value pair with the same key,
+so messages that happen to contain braces, such as "route /users/{id}", are
+left unchanged. Because placeholders are recognised in every message, a message
+written for an earlier version of this package is rendered differently if it
+contains a placeholder whose key is also attached to the error.
+
+When placeholders are replaced, the message template is reported with the key
+"msg.template" wherever the message is reported with the key "msg": by the
+Keyvals method, in the JSON representation, and in the group logged by
+log/slog.
+
+Use the `Join` function when there are several errors to report at once, for
+example when closing several resources. Any nil errors are discarded, and the
+error returned reports each of the errors with its own key/value pairs. Any
+key/value pairs that apply to all of the errors are reported first:
+ err = errors.Join(
+     errors.New("file locked").With("file", "a"),
+     errors.New("file locked").With("file", "b"),
+ ).With("op", "close")
+ fmt.Println(err)
+
+ // Output:
+ // multiple errors op=close: file locked file=a; file locked file=b
+
+A panic can be converted into an error using `Recover`, which is called by a
+deferred function call. The error records the stack trace of the panic, and if
+the panic value is an error, it is the cause:
+ func doSomething() (err error) {
+     defer errors.Recover(&err)
+
+     // ... code that might panic ...
+ }
+
+A `Group` runs functions in goroutines, in the same way as the errgroup
+package, but by default it reports all of the errors rather than just the first.
+Each function can be tagged with its own key/value pairs, and panics are
+reported as errors:
+ g, ctx := errors.NewGroup(ctx)
+ for i, file := range files {
+     file := file
+     g.With("worker", i).Go(func() error {
+         return upload(ctx, file)
+     })
+ }
+ err := g.Wait()
+
+Stack traces
+
+Errors created by `New` and `Wrap`, including those created from a context,
+record the stack trace at the point they were created. The stack trace is
+available using the `StackTrace` method, which is compatible with the
+github.com/pkg/errors package:
+
+ type stackTracer interface {
+     StackTrace() errors.StackTrace
+ }
+
+The error types in this package implement fmt.Formatter. The `%s` and `%v`
+verbs print the same single line as the `Error` method, and `%q` prints it as a
+double-quoted string. The `%+v` verb prints one line for each wrapped error,
+with its own key/value pairs, followed by the stack trace captured for it:
+ fmt.Printf("%+v", err)
+
 Retrieving the cause of an error
 
 Using errors.Wrap constructs a stack of errors, adding context to the
@@ -131,6 +230,56 @@ implement causer, which is assumed to be the original cause. For example:
      // unknown error
  }
 
+Errors created by this package also implement the `Unwrap` method, so they work
+with the `Is` and `As` functions in this package and in the standard library.
+An error returned by the `With` method remembers the error it was derived from,
+so package-level sentinel errors can have key/value pairs attached without
+losing their identity:
+ var ErrNotFound = errors.New("not found")
+
+ // ... later ...
+
+ err := errors.Wrap(ErrNotFound.With("id", id), "cannot get document")
+ fmt.Println(errors.Is(err, ErrNotFound))
+
+ // Output:
+ // true
+
+Classifying errors
+
+An error can be classified by attaching a `Kind`, such as `NotFound` or
+`Conflict`, as the value of a key/value pair. The `KindOf` function returns the
+kind of an error, and because each Kind is also an error, it can be matched
+using the `Is` function:
+ err := errors.Wrap(err, "cannot get document").With("kind", errors.NotFound)
+
+ // ... later ...
+
+ if errors.Is(err, errors.NotFound) {
+     // handle not found
+ }
+
+The `Retryable`, `Temporary` and `RetryAfter` functions help decide whether an
+operation should be retried. Errors are marked using the keys "retryable",
+"temporary" and "retry_after", and errors from other packages that implement
+net.Error are recognised:
+ err := errors.New("rate limit exceeded").With("retry_after", 5*time.Second)
+
+ // ... later ...
+
+ if d, ok := errors.RetryAfter(err); ok {
+     time.Sleep(d)
+ }
+
+Package github.com/jjeffery/errors/grpcerr uses the kind of an error to
+determine the gRPC status code, and sends its key/value pairs to the client
+as error details. Package github.com/jjeffery/errors/problem does the same for
+HTTP APIs, rendering errors as problem details (application/problem+json)
+documents, and package github.com/jjeffery/errors/sentryerr reports errors
+to a Sentry-compatible collector, with an exception for each wrapped error.
+Package github.com/jjeffery/errors/otelerr records errors on OpenTelemetry
+spans, with the key/value pairs as attributes of the exception event.
+
 Retrieving key value pairs for structured logging
 
 Errors created by `errors.Wrap` and `errors.New` implement the following
@@ -144,6 +293,24 @@ The Keyvals method returns an array of alternating keys and values. The
 first key will always be "msg" and its value will be a string containing
 the message associated with the wrapped error.
 
+The key/value pairs attached to each error in the chain are included, so they
+can be indexed by a structured log regardless of how deeply the error was
+wrapped. The messages of the wrapped errors have the keys "cause.msg",
+"cause.cause.msg", and so on. If placeholders in a message are replaced, the
+message template follows it with the key "msg.template", "cause.msg.template", etc:
+ err := errors.With("id", 42).New("file locked")
+ err = errors.Wrap(err, "retry failed").With("attempt", 3)
+ fmt.Println(err.(keyvalser).Keyvals())
+
+ // Output:
+ // [msg retry failed attempt 3 cause.msg file locked id 42]
+
+The `Fingerprint` function returns an identifier that can be used to group
+similar errors. It depends on the message templates, keys and the functions
+that created the errors in the chain, but not on the values. Call
+`SetFingerprintKey` to include the fingerprint in the result of Keyvals:
+ errors.SetFingerprintKey("fingerprint")
+
 Example using go-kit logging (https://github.com/go-kit/kit/tree/master/log):
 
  // logError logs details of an error to a structured error log.
@@ -169,6 +336,63 @@ Example using go-kit logging (https://github.com/go-kit/kit/tree/master/log):
      logger.Log(keyvals...)
  }
 
+Errors created by this package also implement json.Marshaler. Each error in
+the chain is represented by a JSON object with its message, its own key/value
+pairs, and a nested object for its cause. An error from another package is
+represented by an object containing its message. The outermost object also
+contains the fingerprint of the error:
+ {"msg":"retry failed","keyvals":{"attempt":3},
+  "cause":{"msg":"file locked","keyvals":{"file":"testrun"},
+  "cause":{"msg":"permission denied"}},"fingerprint":"5b1c0e3a9d27f846"}
+
+The `UnmarshalJSON` function reconstructs an equivalent error from its JSON
+representation, which is useful when errors are passed between processes.
+Sentinel errors passed to `Register` are restored so that `Is` continues to
+report a match in the receiving process, and the restored error has the same
+fingerprint as the original.
+
+Errors created by this package implement the slog.LogValuer interface, so
+they are logged by the log/slog package as a group containing the message,
+each key/value pair, and a nested group for the cause:
+ logger.Error("request failed", "err", err)
+
+The handler in package github.com/jjeffery/errors/slogerr goes one step
+further, and adds the key/value pairs attached to an error as attributes
+of the log record.
+
+Sensitive values, such as passwords and tokens, can be masked in every
+rendering of an error using a `RedactPolicy`. The policy can be set for all
+errors using `SetRedactPolicy`, and for the errors created from a context
+using the context's `WithRedactPolicy` method. Values wrapped using `Secret`
+are always masked:
+ errors.SetRedactPolicy(&errors.RedactPolicy{
+     Keys:  []string{"password"},
+     Globs: []string{"*_token"},
+ })
+
+ err := errors.New("cannot log in").With("password", password)
+ fmt.Println(err)
+
+ // Output:
+ // cannot log in password=REDACTED
+
+Values that are expensive to compute can be attached using `Lazy`. The value
+is computed the first time the error is rendered, and only if it is not redacted:
+ err = errors.Wrap(err, "cannot process request").With(
+     "request", errors.Lazy(func() interface{} { return dumpRequest(req) }),
+ )
+
+Mistakes in the key/value pairs passed to `With`, such as a missing value, a
+key that is not a string, or an error passed as a value instead of being wrapped,
+are reported by the analyzer in package github.com/jjeffery/errors/keyvalcheck.
+It can be run using go vet:
+ go install github.com/jjeffery/errors/cmd/keyvalcheck
+ go vet -vettool=$(which keyvalcheck) ./...
+
+Package github.com/jjeffery/errors/errorstest provides test assertions that
+examine the messages, key/value pairs, cause and kind of an error, rather than
+comparing the text returned by its Error method.
+
 GOOD ADVICE: Do not use the Keyvals method on an error to retrieve the
 individual key/value pairs associated with an error for processing by the
 calling program.
@@ -2,18 +2,23 @@ package errors
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 )
 
 // errorT represents an error with a message and context.
 type errorT struct {
-	ctx context
-	msg string
+	ctx         context
+	msg         string
+	stack       *stack
+	orig        error  // error this was derived from using With, if any
+	fingerprint string // fingerprint restored by UnmarshalJSON, if any
 }
 
 // Error implements the error interface.
 func (e *errorT) Error() string {
 	var buf bytes.Buffer
-	buf.WriteString(e.msg)
+	buf.WriteString(e.message())
 	e.ctx.writeToBuf(&buf)
 	return buf.String()
 }
@@ -24,6 +29,12 @@ func (e *errorT) With(keyvals ...interface{}) Error {
 	return e.withKeyvals(keyvals)
 }
 
+// Message returns the message of the error, without the key/value
+// pairs or the message of any cause.
+func (e *errorT) Message() string {
+	return e.message()
+}
+
 // MarshalText implements the TextMarshaler interface.
 func (e *errorT) MarshalText() ([]byte, error) {
 	return []byte(e.Error()), nil
@@ -32,16 +43,65 @@ func (e *errorT) MarshalText() ([]byte, error) {
 // Keyvals returns the contents of the error
 // as an array of alternating keys and values.
 func (e *errorT) Keyvals() []interface{} {
+	return appendFingerprint(e.keyvals(), e)
+}
+
+func (e *errorT) keyvals() []interface{} {
 	var keyvals []interface{}
-	keyvals = append(keyvals, "msg", e.msg)
+	keyvals = append(keyvals, "msg", e.message())
+	if e.ctx.isTemplate(e.msg) {
+		keyvals = append(keyvals, "msg.template", e.msg)
+	}
 	keyvals = e.ctx.appendKeyvals(keyvals)
 	return keyvals
 }
 
+// StackTrace returns the stack trace recorded when the error was created.
+// It is compatible with the github.com/pkg/errors package.
+func (e *errorT) StackTrace() StackTrace {
+	return e.stack.StackTrace()
+}
+
+// Format implements the fmt.Formatter interface. The %+v verb
+// prints the message and key/value pairs followed by the stack trace.
+func (e *errorT) Format(s fmt.State, verb rune) {
+	format(s, verb, e)
+}
+
+// formatLayer writes the message, key/value pairs and stack trace
+// for this error to s, without any cause.
+func (e *errorT) formatLayer(s fmt.State) {
+	var buf bytes.Buffer
+	buf.WriteString(e.message())
+	e.ctx.writeToBuf(&buf)
+	s.Write(buf.Bytes())
+	e.stack.Format(s, 'v')
+}
+
+// next returns nil, as an errorT has no cause.
+func (e *errorT) next() error {
+	return nil
+}
+
+// Is reports whether the error was derived from target by
+// calling With, or whether target is the Kind of the error.
+// It is used by the standard library errors.Is function.
+func (e *errorT) Is(target error) bool {
+	return isDerived(e.orig, target) || isKind(e, target)
+}
+
+// derivedFrom returns the error that e was derived from
+// by calling With, or nil.
+func (e *errorT) derivedFrom() error {
+	return e.orig
+}
+
 func (e *errorT) withKeyvals(keyvals []interface{}) *errorT {
 	return &errorT{
-		ctx: e.ctx.withKeyvals(keyvals),
-		msg: e.msg,
+		ctx:   e.ctx.withKeyvals(keyvals),
+		msg:   e.msg,
+		stack: e.stack,
+		orig:  e,
 	}
 }
 
@@ -55,7 +115,7 @@ type causeT struct {
 // Error implements the error interface.
 func (c *causeT) Error() string {
 	var buf bytes.Buffer
-	buf.WriteString(c.msg)
+	buf.WriteString(c.message())
 	c.ctx.writeToBuf(&buf)
 	buf.WriteString(": ")
 	buf.WriteString(c.cause.Error())
@@ -65,8 +125,10 @@ func (c *causeT) Error() string {
 // With returns an error with additional key/value pairs attached.
 // It implements the Error interface.
 func (c *causeT) With(keyvals ...interface{}) Error {
+	e := c.errorT.withKeyvals(keyvals)
+	e.orig = c
 	return &causeT{
-		errorT: c.errorT.withKeyvals(keyvals),
+		errorT: e,
 		cause:  c.cause,
 	}
 }
@@ -76,6 +138,25 @@ func (c *causeT) MarshalText() ([]byte, error) {
 	return []byte(c.Error()), nil
 }
 
+// Format implements the fmt.Formatter interface. The %+v verb
+// prints the message, key/value pairs and stack trace followed
+// by the cause.
+func (c *causeT) Format(s fmt.State, verb rune) {
+	format(s, verb, c)
+}
+
+// Is reports whether the error was derived from target by
+// calling With, or whether target is the Kind of the error.
+// It is used by the standard library errors.Is function.
+func (c *causeT) Is(target error) bool {
+	return isDerived(c.orig, target) || isKind(c, target)
+}
+
+// next returns the cause of the error.
+func (c *causeT) next() error {
+	return c.cause
+}
+
 // Cause implements the causer interface, and is compatible with
 // the github.com/pkg/errors package.
 func (c *causeT) Cause() error {
@@ -91,19 +172,22 @@ func (c *causeT) Unwrap() error {
 // Keyvals returns the contents of the error
 // as an array of alternating keys and values.
 func (c *causeT) Keyvals() []interface{} {
-	keyvals := c.errorT.Keyvals()
+	return appendFingerprint(c.keyvals(), c)
+}
 
-	// TODO(jpj): this might be improved by checking if cause
-	// implements keyvalser, and appending keyvals.
-	keyvals = append(keyvals, "cause", c.cause.Error())
-	return keyvals
+func (c *causeT) keyvals() []interface{} {
+	keyvals := c.errorT.keyvals()
+	return appendCauseKeyvals(keyvals, c.cause)
 }
 
 // attachT represents an error that has additional keyword/value pairs
 // attached to it.
 type attachT struct {
-	ctx   context
-	cause error
+	ctx         context
+	cause       error
+	stack       *stack
+	orig        error  // error this was derived from using With, if any
+	fingerprint string // fingerprint restored by UnmarshalJSON, if any
 }
 
 // Error implements the error interface.
@@ -120,14 +204,61 @@ func (a *attachT) With(keyvals ...interface{}) Error {
 	return &attachT{
 		ctx:   a.ctx.withKeyvals(keyvals),
 		cause: a.cause,
+		stack: a.stack,
+		orig:  a,
 	}
 }
 
+// Is reports whether the error was derived from target by
+// calling With, or whether target is the Kind of the error.
+// It is used by the standard library errors.Is function.
+func (a *attachT) Is(target error) bool {
+	return isDerived(a.orig, target) || isKind(a, target)
+}
+
+// derivedFrom returns the error that a was derived from
+// by calling With, or nil.
+func (a *attachT) derivedFrom() error {
+	return a.orig
+}
+
 // MarshalText implements the TextMarshaler interface.
 func (a *attachT) MarshalText() ([]byte, error) {
 	return []byte(a.Error()), nil
 }
 
+// StackTrace returns the stack trace recorded when the error was created.
+// It is compatible with the github.com/pkg/errors package.
+func (a *attachT) StackTrace() StackTrace {
+	return a.stack.StackTrace()
+}
+
+// Format implements the fmt.Formatter interface. The %+v verb
+// prints the key/value pairs and stack trace followed by the cause.
+func (a *attachT) Format(s fmt.State, verb rune) {
+	format(s, verb, a)
+}
+
+// formatLayer writes the key/value pairs and stack trace for this
+// error to s, without the cause. If there are no key/value pairs,
+// only the stack trace is written.
+func (a *attachT) formatLayer(s fmt.State) {
+	var buf bytes.Buffer
+	a.ctx.writeToBuf(&buf)
+	if buf.Len() == 0 {
+		fmt.Fprintf(&buf, "%+v", a.stack)
+		s.Write(bytes.TrimPrefix(buf.Bytes(), []byte("\n")))
+		return
+	}
+	s.Write(buf.Bytes())
+	a.stack.Format(s, 'v')
+}
+
+// next returns the cause of the error.
+func (a *attachT) next() error {
+	return a.cause
+}
+
 // Cause implements the causer interface, and is compatible with
 // the github.com/pkg/errors package.
 func (a *attachT) Cause() error {
@@ -143,10 +274,93 @@ func (a *attachT) Unwrap() error {
 // Keyvals returns the contents of the error
 // as an array of alternating keys and values.
 func (a *attachT) Keyvals() []interface{} {
+	return appendFingerprint(a.keyvals(), a)
+}
+
+func (a *attachT) keyvals() []interface{} {
 	var keyvals []interface{}
-	// TODO(jpj): this could be improved by checking if the
-	// cause implements the keyvalser interface.
-	keyvals = append(keyvals, "msg", a.cause.Error())
+	causeKeyvals, ok := keyvalsOf(a.cause)
+	if !ok {
+		keyvals = append(keyvals, "msg", a.cause.Error())
+		keyvals = a.ctx.appendKeyvals(keyvals)
+		return keyvals
+	}
+
+	// The cause's message becomes the message for this error,
+	// followed by the attached key/value pairs, and then the
+	// cause's key/value pairs.
+	if len(causeKeyvals) >= 2 && causeKeyvals[0] == "msg" {
+		n := 2
+		if len(causeKeyvals) >= 4 && causeKeyvals[2] == "msg.template" {
+			n = 4
+		}
+		keyvals = append(keyvals, causeKeyvals[:n]...)
+		causeKeyvals = causeKeyvals[n:]
+	} else {
+		keyvals = append(keyvals, "msg", a.cause.Error())
+	}
 	keyvals = a.ctx.appendKeyvals(keyvals)
+	keyvals = append(keyvals, causeKeyvals...)
+	return keyvals
+}
+
+// keyvalser is implemented by errors that provide key/value pairs.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// keyvalsOf returns the key/value pairs of err, if it implements keyvalser.
+// For errors in this package, the fingerprint is not included, as it only
+// applies to the outermost error.
+func keyvalsOf(err error) ([]interface{}, bool) {
+	switch e := err.(type) {
+	case interface{ keyvals() []interface{} }:
+		return e.keyvals(), true
+	case keyvalser:
+		return e.Keyvals(), true
+	}
+	return nil, false
+}
+
+// appendCauseKeyvals appends the key/value pairs for cause to keyvals.
+// If the cause does not implement keyvalser, its message is appended
+// with the key "cause.msg". Otherwise the cause's key/value pairs are
+// appended, and the keys that describe the chain of errors ("msg",
+// keys starting with "msg." or "cause.", and the keys of joined errors)
+// are prefixed with "cause.", so that they remain distinct.
+func appendCauseKeyvals(keyvals []interface{}, cause error) []interface{} {
+	causeKeyvals, ok := keyvalsOf(cause)
+	if !ok {
+		return append(keyvals, "cause.msg", cause.Error())
+	}
+	for i, v := range causeKeyvals {
+		if i%2 == 0 {
+			if key, ok := v.(string); ok && isChainKey(key) {
+				v = "cause." + key
+			}
+		}
+		keyvals = append(keyvals, v)
+	}
 	return keyvals
 }
+
+// IsMessageKey reports whether key is one of the keys used by Keyvals
+// for the messages in the chain of errors: "msg", "msg.template",
+// "cause.msg", "cause.msg.template", and so on. Packages that report
+// the key/value pairs of an error can use it to distinguish the messages
+// from the key/value pairs attached to the error. The messages of joined
+// errors, such as "error.0.msg", are not message keys: the message of a
+// join is "multiple errors", so they are reported with the key/value
+// pairs of the joined errors.
+func IsMessageKey(key string) bool {
+	for strings.HasPrefix(key, "cause.") {
+		key = key[len("cause."):]
+	}
+	return key == "msg" || strings.HasPrefix(key, "msg.")
+}
+
+// isChainKey reports whether key is used by Keyvals to describe the
+// chain of errors, rather than being a key/value pair attached to an error.
+func isChainKey(key string) bool {
+	return key == "msg" || strings.HasPrefix(key, "msg.") || strings.HasPrefix(key, "cause.") || isJoinKey(key)
+}
@@ -8,17 +8,39 @@ type Error interface {
 	With(keyvals ...interface{}) Error
 }
 
-// New returns a new error with a given message.
+// New returns a new error with a given message. The error records
+// the stack trace at the point it was called.
+//
+// The message can contain placeholders such as "{file}", which are
+// replaced with the values of the key/value pairs with the same keys
+// when the error is rendered. A placeholder without a matching key is
+// left unchanged.
 func New(message string) Error {
 	var ctx context
-	return ctx.New(message)
+	return ctx.newError(message, callers())
 }
 
 // Wrap creates an error that wraps an existing error.
+// The error records the stack trace at the point Wrap was called.
 // If err is nil, Wrap returns nil.
 func Wrap(err error, message ...string) Error {
+	if err == nil {
+		return nil
+	}
+	var ctx context
+	return ctx.wrap(err, message, callers())
+}
+
+// Join returns an error that aggregates errs. Any nil errors are
+// discarded, and if there are no errors remaining, Join returns nil.
+//
+// The error returned implements the Unwrap() []error method, so the
+// Is and As functions will examine each of the errors. It does not
+// implement the causer interface, because it does not have a single
+// cause: calling Cause with this error returns the error itself.
+func Join(errs ...error) Error {
 	var ctx context
-	return ctx.Wrap(err, message...)
+	return ctx.join(errs, callers())
 }
 
 // With creates a context with the key/value pairs.
@@ -56,4 +78,8 @@ type Context interface {
 	With(keyvals ...interface{}) Context
 	New(message string) Error
 	Wrap(err error, message ...string) Error
+	Join(errs ...error) Error
+	WithRedactPolicy(policy *RedactPolicy) Context
+	Recover(errp *error)
+	WrapPtr(errp *error, message ...string)
 }
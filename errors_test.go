@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -260,20 +261,45 @@ func TestKeyvals(t *testing.T) {
 		},
 		{
 			err:        Wrap(io.EOF, "message"),
-			errKeyvals: []interface{}{"msg", "message", "cause", "EOF"},
+			errKeyvals: []interface{}{"msg", "message", "cause.msg", "EOF"},
 		},
 		{
 			err:        Wrap(io.EOF, "message").With(),
-			errKeyvals: []interface{}{"msg", "message", "cause", "EOF"},
+			errKeyvals: []interface{}{"msg", "message", "cause.msg", "EOF"},
 		},
 		{
 			err:        Wrap(io.EOF, "message").With("k1", "v1", "k2", 2),
-			errKeyvals: []interface{}{"msg", "message", "k1", "v1", "k2", 2, "cause", "EOF"},
+			errKeyvals: []interface{}{"msg", "message", "k1", "v1", "k2", 2, "cause.msg", "EOF"},
 		},
 		{
 			err:        Wrap(io.EOF, "").With("k1", "v1", "k2", 2),
 			errKeyvals: []interface{}{"msg", "EOF", "k1", "v1", "k2", 2},
 		},
+		{
+			err:        Wrap(New("inner").With("id", 42), "outer").With("k1", "v1"),
+			errKeyvals: []interface{}{"msg", "outer", "k1", "v1", "cause.msg", "inner", "id", 42},
+		},
+		{
+			err: Wrap(Wrap(With("id", 42).Wrap(io.EOF, "first"), "second").With("n", 2), "third"),
+			errKeyvals: []interface{}{
+				"msg", "third",
+				"cause.msg", "second", "n", 2,
+				"cause.cause.msg", "first", "id", 42,
+				"cause.cause.cause.msg", "EOF",
+			},
+		},
+		{
+			err:        Wrap(New("inner").With("id", 42)).With("k1", "v1"),
+			errKeyvals: []interface{}{"msg", "inner", "k1", "v1", "id", 42},
+		},
+		{
+			err:        Wrap(Wrap(io.EOF, "inner").With("id", 42)).With("k1", "v1"),
+			errKeyvals: []interface{}{"msg", "inner", "k1", "v1", "id", 42, "cause.msg", "EOF"},
+		},
+		{
+			err:        Wrap(Wrap(New("inner").With("id", 42)).With("k1", "v1"), "outer"),
+			errKeyvals: []interface{}{"msg", "outer", "cause.msg", "inner", "k1", "v1", "id", 42},
+		},
 		{
 			ctx:        With(),
 			ctxKeyvals: nil,
@@ -378,3 +404,398 @@ type panicingStringer string
 func (s panicingStringer) String() string {
 	panic(s)
 }
+
+type panicingError string
+
+func (e panicingError) Error() string {
+	panic(e)
+}
+
+func TestSafeText(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{value: panicingStringer("no"), want: "PANIC"},
+		{value: panicingError("no"), want: "PANIC"},
+		{value: testStringer("yes"), want: "Stringer: yes"},
+		{value: io.EOF, want: "EOF"},
+		{value: 42, want: 42},
+	}
+	for i, tt := range tests {
+		if got := safeText(tt.value); got != tt.want {
+			t.Errorf("%d: got %v, want %v", i, got, tt.want)
+		}
+	}
+
+	err := New("msg").With("p1", panicingStringer("no"), "p2", panicingError("no"))
+	if got, want := err.Error(), "msg p1=PANIC p2=PANIC"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	type stackTracer interface {
+		StackTrace() StackTrace
+	}
+	tests := []struct {
+		err error
+	}{
+		{err: New("message")},
+		{err: New("message").With("k1", "v1")},
+		{err: Wrap(io.EOF, "message")},
+		{err: Wrap(io.EOF, "message").With("k1", "v1")},
+		{err: Wrap(io.EOF)},
+		{err: Wrap(io.EOF).With("k1", "v1")},
+		{err: With("k1", "v1").New("message")},
+		{err: With("k1", "v1").Wrap(io.EOF, "message")},
+		{err: With("k1", "v1").Wrap(io.EOF)},
+	}
+
+	for i, tt := range tests {
+		st, ok := tt.err.(stackTracer)
+		if !ok {
+			t.Errorf("%d: expected StackTrace(), none available", i)
+			continue
+		}
+		frames := st.StackTrace()
+		if len(frames) == 0 {
+			t.Errorf("%d: expected stack trace, got none", i)
+			continue
+		}
+		if got, want := fmt.Sprintf("%n", frames[0]), "TestStackTrace"; got != want {
+			t.Errorf("%d: want %q, got %q", i, want, got)
+		}
+		if got, want := fmt.Sprintf("%s", frames[0]), "errors_test.go"; got != want {
+			t.Errorf("%d: want %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestFormatStackTrace(t *testing.T) {
+	err := Wrap(New("file locked").With("file", "testrun"), "retry failed").With("attempt", 3)
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+
+	if got, want := lines[0], "retry failed attempt=3"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got, want := lines[1], "github.com/jjeffery/errors.TestFormatStackTrace"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+	if got := lines[2]; !strings.HasPrefix(got, "\t") || !strings.Contains(got, "errors_test.go:") {
+		t.Fatalf("want file and line, got %q", got)
+	}
+
+	var found bool
+	for i, line := range lines {
+		if line == "file locked file=testrun" {
+			found = true
+			if got, want := lines[i+1], "github.com/jjeffery/errors.TestFormatStackTrace"; got != want {
+				t.Fatalf("want %q, got %q", want, got)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("missing cause in %q", lines)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		err    error
+		format string
+		want   string
+	}{
+		{
+			err:    New("error message").With("a", 1),
+			format: "%s",
+			want:   "error message a=1",
+		},
+		{
+			err:    New("error message").With("a", 1),
+			format: "%v",
+			want:   "error message a=1",
+		},
+		{
+			err:    New("error message").With("a", 1),
+			format: "%q",
+			want:   `"error message a=1"`,
+		},
+		{
+			err:    New("error message").With("a", 1),
+			format: "%+v",
+			want:   "error message a=1",
+		},
+		{
+			err:    Wrap(io.EOF, "error message").With("b", "two words"),
+			format: "%v",
+			want:   `error message b="two words": EOF`,
+		},
+		{
+			err:    Wrap(io.EOF, "error message").With("b", "two words"),
+			format: "%q",
+			want:   `"error message b=\"two words\": EOF"`,
+		},
+		{
+			err:    Wrap(io.EOF, "error message").With("b", "two words"),
+			format: "%+v",
+			want:   "error message b=\"two words\"\nEOF",
+		},
+		{
+			err:    Wrap(io.EOF).With("c", 3),
+			format: "%s",
+			want:   "EOF c=3",
+		},
+		{
+			err:    Wrap(io.EOF).With("c", 3),
+			format: "%+v",
+			want:   "c=3\nEOF",
+		},
+		{
+			err:    Wrap(io.EOF),
+			format: "%+v",
+			want:   "EOF",
+		},
+		{
+			err:    With("attempt", 3).Wrap(New("file locked").With("file", "testrun"), "retry failed"),
+			format: "%+v",
+			want:   "retry failed attempt=3\nfile locked file=testrun",
+		},
+		{
+			err:    Wrap(verboseError("short"), "outer"),
+			format: "%+v",
+			want:   "outer\nverbose: short",
+		},
+		{
+			err:    Wrap(verboseError("short"), "outer"),
+			format: "%v",
+			want:   "outer: short",
+		},
+	}
+
+	for i, tt := range tests {
+		got := withoutStackTrace(fmt.Sprintf(tt.format, tt.err))
+		if got != tt.want {
+			t.Errorf("%d: %s: want %q, got %q", i, tt.format, tt.want, got)
+		}
+	}
+
+	// a layer without key/value pairs starts with its stack trace
+	got := fmt.Sprintf("%+v", Join(Wrap(io.EOF)))
+	if want := "\n    [0] github.com/jjeffery/errors.TestFormat\n"; !strings.Contains(got, want) {
+		t.Errorf("want %q in %q", want, got)
+	}
+}
+
+// withoutStackTrace removes stack trace frames from the output of %+v.
+// Each frame is printed as a function name, followed by a line that
+// starts with a tab. Frames may be indented with spaces.
+func withoutStackTrace(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "\t") {
+			// remove the function name line as well
+			lines = lines[:len(lines)-1]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// verboseError is an error from another package that implements
+// fmt.Formatter.
+type verboseError string
+
+func (e verboseError) Error() string {
+	return string(e)
+}
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		io.WriteString(s, "verbose: ")
+	}
+	io.WriteString(s, string(e))
+}
+
+var errSentinel = New("sentinel")
+
+var errWrappedSentinel = Wrap(io.EOF, "wrapped sentinel")
+
+var errAttachedSentinel = Wrap(io.EOF).With("k", "v")
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{err: errSentinel, target: errSentinel, want: true},
+		{err: errSentinel.With("id", 1), target: errSentinel, want: true},
+		{err: errSentinel.With("id", 1).With("n", 2), target: errSentinel, want: true},
+		{err: Wrap(errSentinel.With("id", 1), "outer"), target: errSentinel, want: true},
+		{err: Wrap(Wrap(errSentinel.With("id", 1)).With("a", 1), "outer").With("b", 2), target: errSentinel, want: true},
+		{err: With("a", 1).Wrap(errSentinel, "outer"), target: errSentinel, want: true},
+		{err: errWrappedSentinel.With("id", 1), target: errWrappedSentinel, want: true},
+		{err: errWrappedSentinel.With("id", 1), target: io.EOF, want: true},
+		{err: errAttachedSentinel.With("id", 1), target: errAttachedSentinel, want: true},
+		{err: New("sentinel"), target: errSentinel, want: false},
+		{err: New("sentinel").With("id", 1), target: errSentinel, want: false},
+		{err: errSentinel.With("id", 1), target: io.EOF, want: false},
+		{err: errWrappedSentinel.With("id", 1), target: errSentinel, want: false},
+	}
+
+	for i, tt := range tests {
+		if got := Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("%d: Is(%v, %v): want %v, got %v", i, tt.err, tt.target, tt.want, got)
+		}
+	}
+}
+
+func TestAs(t *testing.T) {
+	err := Wrap(&nilCauseError{}, "outer").With("k", "v")
+	var target *nilCauseError
+	if !As(err, &target) {
+		t.Fatalf("As: want true, got false")
+	}
+	if target == nil {
+		t.Fatalf("As: want target set, got nil")
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	if got, want := Unwrap(Wrap(io.EOF, "outer")), io.EOF; got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got := Unwrap(New("message")); got != nil {
+		t.Errorf("want nil, got %v", got)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	e1 := New("file locked").With("file", "a")
+	e2 := Wrap(io.EOF, "cannot read").With("file", "b")
+
+	if got := Join(); got != nil {
+		t.Errorf("Join(): want nil, got %v", got)
+	}
+	if got := Join(nil, nil); got != nil {
+		t.Errorf("Join(nil, nil): want nil, got %v", got)
+	}
+	if got := With("k", "v").Join(nil); got != nil {
+		t.Errorf("Context.Join(nil): want nil, got %v", got)
+	}
+
+	tests := []struct {
+		err     Error
+		text    string
+		keyvals []interface{}
+	}{
+		{
+			err:  Join(e1, nil, e2),
+			text: "file locked file=a; cannot read file=b: EOF",
+			keyvals: []interface{}{
+				"msg", "multiple errors",
+				"error.0.msg", "file locked", "error.0.file", "a",
+				"error.1.msg", "cannot read", "error.1.file", "b", "error.1.cause.msg", "EOF",
+			},
+		},
+		{
+			err:  Join(e1, e2).With("op", "close"),
+			text: "multiple errors op=close: file locked file=a; cannot read file=b: EOF",
+			keyvals: []interface{}{
+				"msg", "multiple errors", "op", "close",
+				"error.0.msg", "file locked", "error.0.file", "a",
+				"error.1.msg", "cannot read", "error.1.file", "b", "error.1.cause.msg", "EOF",
+			},
+		},
+		{
+			err:     With("op", "close").Join(io.EOF),
+			text:    "multiple errors op=close: EOF",
+			keyvals: []interface{}{"msg", "multiple errors", "op", "close", "error.0.msg", "EOF"},
+		},
+		{
+			err:  Wrap(Join(e1, io.EOF), "cannot close").With("op", "close"),
+			text: "cannot close op=close: file locked file=a; EOF",
+			keyvals: []interface{}{
+				"msg", "cannot close", "op", "close",
+				"cause.msg", "multiple errors",
+				"cause.error.0.msg", "file locked", "cause.error.0.file", "a",
+				"cause.error.1.msg", "EOF",
+			},
+		},
+		{
+			err:  Wrap(Join(e1, io.EOF)).With("op", "close"),
+			text: "file locked file=a; EOF op=close",
+			keyvals: []interface{}{
+				"msg", "multiple errors", "op", "close",
+				"error.0.msg", "file locked", "error.0.file", "a",
+				"error.1.msg", "EOF",
+			},
+		},
+		{
+			err:  Join(Join(e1), io.EOF),
+			text: "file locked file=a; EOF",
+			keyvals: []interface{}{
+				"msg", "multiple errors",
+				"error.0.msg", "multiple errors",
+				"error.0.error.0.msg", "file locked", "error.0.error.0.file", "a",
+				"error.1.msg", "EOF",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		if got, want := tt.err.Error(), tt.text; got != want {
+			t.Errorf("%d: want %q, got %q", i, want, got)
+		}
+		if got, want := tt.err.(interface{ Keyvals() []interface{} }).Keyvals(), tt.keyvals; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: want %v, got %v", i, want, got)
+		}
+	}
+
+	err := Join(e1, e2).With("op", "close")
+	if !Is(err, e1) || !Is(err, e2) || !Is(err, io.EOF) {
+		t.Errorf("Is: want match for each error in %v", err)
+	}
+	if got, want := Cause(err), error(err); got != want {
+		t.Errorf("Cause: want %v, got %v", want, got)
+	}
+	if got, want := len(err.(interface{ Unwrap() []error }).Unwrap()), 2; got != want {
+		t.Errorf("Unwrap: want %d errors, got %d", want, got)
+	}
+
+	sentinel := Join(e1, e2)
+	if !Is(sentinel.With("k", "v"), sentinel) {
+		t.Errorf("Is: want joined error to match after With")
+	}
+
+	verbose := withoutStackTrace(fmt.Sprintf("%+v", err))
+	want := "multiple errors op=close\n    [0] file locked file=a\n    [1] cannot read file=b\n    EOF"
+	if verbose != want {
+		t.Errorf("%%+v: want %q, got %q", want, verbose)
+	}
+}
+
+func TestIsMessageKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"msg", true},
+		{"msg.template", true},
+		{"cause.msg", true},
+		{"cause.cause.msg.template", true},
+		{"message", false},
+		{"cause", false},
+		{"id", false},
+		{"error.0", false},
+		{"error.0.msg", false},
+		{"cause.error.0.msg", false},
+	}
+	for _, tt := range tests {
+		if got := IsMessageKey(tt.key); got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.key, tt.want, got)
+		}
+	}
+}
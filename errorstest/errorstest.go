@@ -0,0 +1,324 @@
+// Package errorstest provides assertions for testing the errors created
+// using package github.com/jjeffery/errors.
+//
+// The assertions examine the structure of an error, rather than the text
+// returned by its Error method, so that tests are not affected by changes
+// to the order or quoting of the key/value pairs. When an assertion fails,
+// it reports the chain of errors, one error per line, with the key/value
+// pairs of each error.
+//
+// Each assertion reports whether it succeeded, so that a test can stop
+// when an assertion fails:
+//  if !errorstest.KindIs(t, err, errors.NotFound) {
+//      return
+//  }
+package errorstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/errors"
+)
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// messager is implemented by the errors in package github.com/jjeffery/errors
+// that have a message.
+type messager interface {
+	Message() string
+}
+
+// HasKeyval asserts that a key/value pair with key and value is attached
+// to err, or to any error in its chain. Values are compared using
+// reflect.DeepEqual.
+func HasKeyval(t testing.TB, err error, key string, value interface{}) bool {
+	t.Helper()
+	if err == nil {
+		t.Errorf("want key/value pair %s=%v, got nil error", key, value)
+		return false
+	}
+	// The key/value pairs of an error wrapped by an error
+	// from another package are not included in the Keyvals
+	// of the outer errors, so examine every error in the chain.
+	var found []interface{}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		kv, ok := e.(keyvalser)
+		if !ok {
+			continue
+		}
+		keyvals := kv.Keyvals()
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			if keyvals[i] != key {
+				continue
+			}
+			if reflect.DeepEqual(keyvals[i+1], value) {
+				return true
+			}
+			found = append(found, keyvals[i+1])
+		}
+	}
+	if len(found) > 0 {
+		t.Errorf("want key/value pair %s=%#v, got %s=%#v in error chain:\n%s",
+			key, value, key, found[len(found)-1], Chain(err))
+	} else {
+		t.Errorf("want key/value pair %s=%#v, key not found in error chain:\n%s", key, value, Chain(err))
+	}
+	return false
+}
+
+// HasMessageChain asserts that the messages of the errors in the chain of err
+// are msgs, starting with the outermost error. Errors that attach key/value
+// pairs to their cause without a message are not included in the chain.
+func HasMessageChain(t testing.TB, err error, msgs ...string) bool {
+	t.Helper()
+	got := Messages(err)
+	if reflect.DeepEqual(got, msgs) || len(got) == 0 && len(msgs) == 0 {
+		return true
+	}
+	t.Errorf("message chain does not match (-want +got):\n%s\nerror chain:\n%s", diff(msgs, got), Chain(err))
+	return false
+}
+
+// CauseIs asserts that the cause of err matches target, as reported by
+// errors.Is. The cause is the innermost error in the chain, found by
+// following the Cause and Unwrap methods.
+func CauseIs(t testing.TB, err error, target error) bool {
+	t.Helper()
+	if cause := rootCause(err); cause == nil || !errors.Is(cause, target) {
+		t.Errorf("want cause %q, got %q in error chain:\n%s", errorString(target), errorString(cause), Chain(err))
+		return false
+	}
+	return true
+}
+
+// KindIs asserts that the kind of err, as returned by errors.KindOf, is kind.
+func KindIs(t testing.TB, err error, kind errors.Kind) bool {
+	t.Helper()
+	if got := errors.KindOf(err); got != kind {
+		t.Errorf("want kind %q, got %q in error chain:\n%s", kind, got, Chain(err))
+		return false
+	}
+	return true
+}
+
+// Golden asserts that the JSON representation of err, indented for
+// readability, matches the contents of the file testdata/name.golden.
+// If update is true, the file is written instead. The update argument
+// is typically the value of a flag defined by the test package:
+//  var update = flag.Bool("update", false, "update golden files")
+//
+//  func TestLoad(t *testing.T) {
+//      errorstest.Golden(t, load("missing.txt"), "load", *update)
+//  }
+func Golden(t testing.TB, err error, name string, update bool) bool {
+	t.Helper()
+	data, jsonErr := json.MarshalIndent(err, "", "  ")
+	if jsonErr != nil {
+		t.Fatalf("cannot marshal error: %v", jsonErr)
+		return false
+	}
+	data = append(data, '\n')
+	file := filepath.Join("testdata", name+".golden")
+	if update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatalf("cannot create directory: %v", err)
+			return false
+		}
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			t.Fatalf("cannot update golden file: %v", err)
+			return false
+		}
+		return true
+	}
+	want, readErr := os.ReadFile(file)
+	if readErr != nil {
+		t.Fatalf("cannot read golden file (call Golden with update set to create it): %v", readErr)
+		return false
+	}
+	if !bytes.Equal(want, data) {
+		t.Errorf("%s does not match (-want +got):\n%s", file, diff(lines(string(want)), lines(string(data))))
+		return false
+	}
+	return true
+}
+
+// Messages returns the messages of the errors in the chain of err,
+// starting with the outermost error. Errors that attach key/value
+// pairs to their cause without a message are not included.
+//
+// The message of an error from another package that wraps an error is
+// the text of the error, without the text of the error that it wraps.
+func Messages(err error) []string {
+	var msgs []string
+	for _, layer := range layers(err) {
+		if layer.hasMsg {
+			msgs = append(msgs, layer.msg)
+		}
+	}
+	return msgs
+}
+
+// Chain returns a description of the chain of errors in err, with one
+// line for each error, starting with the outermost error. Each line
+// contains the message and the key/value pairs attached to the error.
+func Chain(err error) string {
+	if err == nil {
+		return "  <nil>"
+	}
+	var buf strings.Builder
+	for i, layer := range layers(err) {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("  ")
+		if layer.hasMsg {
+			fmt.Fprintf(&buf, "%q", layer.msg)
+		} else {
+			buf.WriteString("(no message)")
+		}
+		if len(layer.keyvals) > 0 {
+			buf.WriteByte(' ')
+			buf.Write(layer.keyvals)
+		}
+	}
+	return buf.String()
+}
+
+// layer describes one error in a chain.
+type layer struct {
+	msg     string
+	hasMsg  bool
+	keyvals json.RawMessage // JSON object, if any
+}
+
+// layers returns the errors in the chain of err, outermost first.
+func layers(err error) []layer {
+	var result []layer
+	for err != nil {
+		next := errors.Unwrap(err)
+		var l layer
+		switch e := err.(type) {
+		case messager:
+			l.msg, l.hasMsg = e.Message(), true
+		case keyvalser:
+			// attaches key/value pairs to its cause, or aggregates errors
+			if next == nil {
+				l.msg, l.hasMsg = err.Error(), true
+			}
+		default:
+			l.msg, l.hasMsg = err.Error(), true
+			if next != nil {
+				l.msg = strings.TrimSuffix(l.msg, ": "+next.Error())
+			}
+		}
+		l.keyvals = ownKeyvals(err)
+		result = append(result, l)
+		err = next
+	}
+	return result
+}
+
+// ownKeyvals returns the key/value pairs attached to err, but not its
+// cause, as a JSON object.
+func ownKeyvals(err error) json.RawMessage {
+	m, ok := err.(json.Marshaler)
+	if !ok {
+		return nil
+	}
+	data, jsonErr := m.MarshalJSON()
+	if jsonErr != nil {
+		return nil
+	}
+	var v struct {
+		Keyvals json.RawMessage `json:"keyvals"`
+	}
+	if json.Unmarshal(data, &v) != nil {
+		return nil
+	}
+	return v.Keyvals
+}
+
+// diff returns a line-by-line comparison of want and got.
+// Lines that are the same are indented, lines that are only
+// in want are marked with "-", and lines that are only in
+// got are marked with "+".
+func diff(want, got []string) string {
+	// longest common subsequence
+	lcs := make([][]int, len(want)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var buf strings.Builder
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			fmt.Fprintf(&buf, "  %s\n", want[i])
+			i++
+			j++
+		case j < len(got) && (i == len(want) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&buf, "+ %s\n", got[j])
+			j++
+		default:
+			fmt.Fprintf(&buf, "- %s\n", want[i])
+			i++
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// lines splits s into lines.
+func lines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// rootCause returns the innermost error in the chain of err.
+func rootCause(err error) error {
+	type causer interface {
+		Cause() error
+	}
+	for err != nil {
+		var next error
+		switch e := err.(type) {
+		case causer:
+			next = e.Cause()
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		}
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return err
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
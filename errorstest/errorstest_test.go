@@ -0,0 +1,151 @@
+package errorstest_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/errorstest"
+)
+
+// recorder records the failures reported by an assertion.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+var errLocked = errors.New("file locked")
+
+func newError() error {
+	err := errLocked.With("file", "testrun", "id", 42)
+	return errors.Wrap(
+		errors.Wrap(fmt.Errorf("cannot open: %w", err)).With("user", "bob"),
+		"retry failed",
+	).With("attempt", 3, "kind", errors.Conflict)
+}
+
+func TestAssertions(t *testing.T) {
+	err := newError()
+	var r recorder
+	ok := errorstest.HasKeyval(&r, err, "id", 42) &&
+		errorstest.HasKeyval(&r, err, "attempt", 3) &&
+		errorstest.HasKeyval(&r, err, "user", "bob") &&
+		errorstest.HasMessageChain(&r, err, "retry failed", "cannot open", "file locked") &&
+		errorstest.CauseIs(&r, err, errLocked) &&
+		errorstest.KindIs(&r, err, errors.Conflict)
+	if !ok || len(r.errors) > 0 {
+		t.Errorf("want no failures, got %q", r.errors)
+	}
+
+	joined := errors.Join(errLocked.With("worker", 0), io.EOF)
+	ok = errorstest.HasKeyval(&r, joined, "error.0.worker", 0) &&
+		errorstest.HasKeyval(&r, joined, "error.0.msg", "file locked") &&
+		errorstest.HasKeyval(&r, joined, "error.1.msg", "EOF")
+	if !ok || len(r.errors) > 0 {
+		t.Errorf("joined: want no failures, got %q", r.errors)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	err := newError()
+	tests := []struct {
+		assert func(t testing.TB) bool
+		want   []string
+	}{
+		{
+			assert: func(t testing.TB) bool { return errorstest.HasKeyval(t, err, "attempt", 4) },
+			want:   []string{"want key/value pair attempt=4, got attempt=3 in error chain:"},
+		},
+		{
+			assert: func(t testing.TB) bool { return errorstest.HasKeyval(t, err, "missing", 1) },
+			want:   []string{"want key/value pair missing=1, key not found in error chain:"},
+		},
+		{
+			assert: func(t testing.TB) bool { return errorstest.HasKeyval(t, nil, "id", 1) },
+			want:   []string{"want key/value pair id=1, got nil error"},
+		},
+		{
+			assert: func(t testing.TB) bool {
+				return errorstest.HasMessageChain(t, err, "retry failed", "cannot read", "file locked")
+			},
+			want: []string{
+				"message chain does not match (-want +got):",
+				"  retry failed",
+				"- cannot read",
+				"+ cannot open",
+				"  file locked",
+				`  "retry failed" {"attempt":3,"kind":"conflict"}`,
+				`  (no message) {"user":"bob"}`,
+				`  "cannot open"`,
+				`  "file locked" {"file":"testrun","id":42}`,
+			},
+		},
+		{
+			assert: func(t testing.TB) bool { return errorstest.CauseIs(t, err, io.EOF) },
+			want:   []string{`want cause "EOF", got "file locked file=testrun id=42" in error chain:`},
+		},
+		{
+			assert: func(t testing.TB) bool { return errorstest.KindIs(t, err, errors.NotFound) },
+			want:   []string{`want kind "not_found", got "conflict" in error chain:`},
+		},
+	}
+	for i, tt := range tests {
+		var r recorder
+		if tt.assert(&r) {
+			t.Errorf("%d: want assertion to fail", i)
+		}
+		if len(r.errors) != 1 {
+			t.Errorf("%d: want 1 failure, got %d", i, len(r.errors))
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(r.errors[0], want) {
+				t.Errorf("%d: want failure to contain %q, got:\n%s", i, want, r.errors[0])
+			}
+		}
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	errorstest.Golden(t, newError(), "error", *update)
+	if *update {
+		return
+	}
+
+	var r recorder
+	if errorstest.Golden(&r, errors.New("file locked"), "error", false) {
+		t.Error("want golden file not to match")
+	}
+	if errorstest.Golden(&r, newError(), "missing", false) {
+		t.Error("want missing golden file to fail")
+	}
+	if len(r.errors) != 2 || !strings.Contains(r.errors[0], `-   "msg": "retry failed",`) {
+		t.Errorf("unexpected failures %q", r.errors)
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	err := newError()
+	if !errorstest.Golden(t, err, "updated", true) {
+		t.Fatal("want golden file to be written")
+	}
+	if !errorstest.Golden(t, err, "updated", false) {
+		t.Error("want written golden file to match")
+	}
+}
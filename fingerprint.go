@@ -0,0 +1,154 @@
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sync/atomic"
+)
+
+// Fingerprint returns an identifier for grouping similar errors. Errors
+// have the same fingerprint if they have the same chain of message
+// templates and keys, and were created by the same functions. The values
+// of the key/value pairs do not contribute to the fingerprint, so errors
+// that differ only in their values have the same fingerprint.
+//
+// The fingerprint is a string of hexadecimal digits, and is the same in
+// every process and on every architecture for the same program. An error
+// from another package contributes its type and message, unless it wraps
+// another error, in which case it contributes its type and the errors it wraps.
+//
+// An error restored by UnmarshalJSON has the same fingerprint as the
+// original error, because the fingerprint is included in the JSON. Key/value
+// pairs attached to the restored error using With change the fingerprint.
+//
+// If err is nil, Fingerprint returns an empty string.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	if fingerprint := restoredFingerprint(err); fingerprint != "" {
+		return fingerprint
+	}
+	fp := fingerprinter{h: sha256.New()}
+	fp.error(err)
+	sum := fp.h.Sum(nil)
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintKey is the key used for the fingerprint in Keyvals.
+var fingerprintKey atomic.Value
+
+// SetFingerprintKey causes the Keyvals method of the errors in this package
+// to include the fingerprint of the error as a key/value pair with the key,
+// after all of the other key/value pairs. An empty key, which is the
+// default, means that the fingerprint is not included. See Fingerprint.
+func SetFingerprintKey(key string) {
+	fingerprintKey.Store(key)
+}
+
+// appendFingerprint appends the fingerprint of err to keyvals, if a
+// fingerprint key has been set using SetFingerprintKey.
+func appendFingerprint(keyvals []interface{}, err error) []interface{} {
+	if key, _ := fingerprintKey.Load().(string); key != "" {
+		keyvals = append(keyvals, key, Fingerprint(err))
+	}
+	return keyvals
+}
+
+// fingerprinter writes the parts of an error that contribute to its
+// fingerprint to a hash. Each string is preceded by its length, so
+// that the hash does not depend on where strings are split.
+type fingerprinter struct {
+	h hash.Hash
+}
+
+func (fp fingerprinter) error(err error) {
+	if fingerprint := restoredFingerprint(err); fingerprint != "" {
+		// the rest of the chain was used to calculate the fingerprint
+		fp.string("restored")
+		fp.string(fingerprint)
+		return
+	}
+	switch e := err.(type) {
+	case *errorT:
+		fp.string("new")
+		fp.layer(e.msg, e.ctx, e.stack)
+	case *causeT:
+		fp.string("wrap")
+		fp.layer(e.msg, e.ctx, e.stack)
+		fp.error(e.cause)
+	case *attachT:
+		fp.string("attach")
+		fp.layer("", e.ctx, e.stack)
+		fp.error(e.cause)
+	case *joinT:
+		fp.string("join")
+		fp.layer("", e.ctx, e.stack)
+		fp.errors(e.errs)
+	case interface{ Unwrap() error }:
+		fp.string(fmt.Sprintf("%T", err))
+		if cause := e.Unwrap(); cause != nil {
+			fp.error(cause)
+		}
+	case interface{ Unwrap() []error }:
+		fp.string(fmt.Sprintf("%T", err))
+		fp.errors(e.Unwrap())
+	default:
+		fp.string(fmt.Sprintf("%T", err))
+		fp.string(err.Error())
+	}
+}
+
+func (fp fingerprinter) errors(errs []error) {
+	fp.int(len(errs))
+	for _, err := range errs {
+		if err != nil {
+			fp.error(err)
+		}
+	}
+}
+
+// layer writes the message template, the keys of the context, and the
+// function that created the error.
+func (fp fingerprinter) layer(msg string, ctx context, st *stack) {
+	fp.string(msg)
+	fp.int((len(ctx.keyvals) + 1) / 2)
+	for i := 0; i < len(ctx.keyvals); i += 2 {
+		fp.string(keyString(ctx.keyvals[i]))
+	}
+	var function string
+	if trace := st.StackTrace(); len(trace) > 0 {
+		function = trace[0].name()
+	}
+	fp.string(function)
+}
+
+// restoredFingerprint returns the fingerprint restored by UnmarshalJSON
+// for err, or an empty string if err was not restored by UnmarshalJSON.
+func restoredFingerprint(err error) string {
+	switch e := err.(type) {
+	case *errorT:
+		return e.fingerprint
+	case *causeT:
+		return e.fingerprint
+	case *attachT:
+		return e.fingerprint
+	case *joinT:
+		return e.fingerprint
+	}
+	return ""
+}
+
+func (fp fingerprinter) string(s string) {
+	fp.int(len(s))
+	fp.h.Write([]byte(s))
+}
+
+func (fp fingerprinter) int(n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	fp.h.Write(b[:])
+}
@@ -0,0 +1,113 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	newError := func(file string, line int) error {
+		return Wrap(With("file", file).New("cannot open {file}"), "cannot load").With("line", line)
+	}
+	otherFunction := func(file string, line int) error {
+		return Wrap(With("file", file).New("cannot open {file}"), "cannot load").With("line", line)
+	}
+
+	fp := Fingerprint(newError("a", 1))
+	if len(fp) != 16 {
+		t.Errorf("unexpected fingerprint %q", fp)
+	}
+	if got := Fingerprint(newError("b", 2)); got != fp {
+		t.Errorf("want same fingerprint for different values, got %q and %q", fp, got)
+	}
+
+	different := []error{
+		otherFunction("a", 1),
+		Wrap(With("file", "a").New("cannot open {file}"), "cannot load"),
+		Wrap(With("path", "a").New("cannot open {file}"), "cannot load").With("line", 1),
+		fmt.Errorf("cannot open a"),
+		nil,
+	}
+	for i, err := range different {
+		if got := Fingerprint(err); got == fp {
+			t.Errorf("%d: want different fingerprint, got %q", i, got)
+		}
+	}
+
+	if Fingerprint(fmt.Errorf("cannot open a")) == Fingerprint(fmt.Errorf("cannot open b")) {
+		t.Error("want different fingerprints for different foreign messages")
+	}
+
+	// errors without a stack have a fingerprint that does not
+	// depend on the process or architecture
+	err := &causeT{
+		errorT: &errorT{msg: "retry failed", ctx: context{keyvals: []interface{}{"attempt", 3}}},
+		cause:  &errorT{msg: "file locked"},
+	}
+	if got, want := Fingerprint(err), "0615a50af73370ef"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestFingerprintKeyvals(t *testing.T) {
+	SetFingerprintKey("fingerprint")
+	defer SetFingerprintKey("")
+
+	inner := New("file locked")
+	err := Wrap(inner, "retry failed")
+	want := []interface{}{
+		"msg", "retry failed",
+		"cause.msg", "file locked",
+		"fingerprint", Fingerprint(err),
+	}
+	if got := err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	attached := Wrap(inner).With("n", 1)
+	want = []interface{}{
+		"msg", "file locked",
+		"n", 1,
+		"fingerprint", Fingerprint(attached),
+	}
+	if got := attached.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestFingerprintJSON(t *testing.T) {
+	err := Wrap(With("file", "a").New("cannot open {file}"), "cannot load").With("line", 1)
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	restored, jsonErr := UnmarshalJSON(data)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if got, want := Fingerprint(restored), Fingerprint(err); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got := Fingerprint(restored.With("n", 1)); got == Fingerprint(err) {
+		t.Errorf("want different fingerprint after With, got %q", got)
+	}
+
+	// a restored error contributes its fingerprint when it is wrapped
+	other := func() error {
+		return Wrap(With("file", "a").New("cannot open {file}"), "cannot load").With("line", 1)
+	}()
+	data, _ = json.Marshal(other)
+	restoredOther, _ := UnmarshalJSON(data)
+	wrap := func(err error) error {
+		return &causeT{errorT: &errorT{msg: "outer"}, cause: err}
+	}
+	if Fingerprint(wrap(restored)) == Fingerprint(wrap(restoredOther)) {
+		t.Errorf("want different fingerprints for errors created by different functions")
+	}
+	if Fingerprint(wrap(restored)) == Fingerprint(wrap(io.EOF)) {
+		t.Errorf("want different fingerprints for different causes")
+	}
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+)
+
+// layer is implemented by each of the error types in this package. A
+// layer knows how to format itself without its cause, and how to
+// obtain the next layer in the chain.
+type layer interface {
+	error
+	formatLayer(s fmt.State)
+	next() error
+}
+
+// format implements the fmt.Formatter interface for the error types in
+// this package.
+//
+//    %s    the error message, including key/value pairs
+//    %v    equivalent to %s
+//    %q    the error message as a double-quoted Go string
+//    %+v   each layer of the error on its own line, with its own
+//          key/value pairs and stack trace
+func format(s fmt.State, verb rune, err error) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			formatVerbose(s, err)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+// formatVerbose writes one line for each layer in the error chain,
+// starting with the outermost layer. Each line is followed by the stack
+// trace recorded for that layer, if any. An error from another package
+// is formatted using %+v, so that it can print its own details.
+func formatVerbose(s fmt.State, err error) {
+	w := &countingState{State: s}
+	s = w
+	for err != nil {
+		if w.n > 0 {
+			io.WriteString(s, "\n")
+		}
+		l, ok := err.(layer)
+		if !ok {
+			fmt.Fprintf(s, "%+v", err)
+			return
+		}
+		l.formatLayer(s)
+		err = l.next()
+	}
+}
+
+// countingState counts the bytes written to a fmt.State, so that
+// formatVerbose does not write a line separator before anything
+// has been written.
+type countingState struct {
+	fmt.State
+	n int
+}
+
+// Write writes b to the underlying fmt.State and counts the bytes written.
+func (s *countingState) Write(b []byte) (int, error) {
+	n, err := s.State.Write(b)
+	s.n += n
+	return n, err
+}
@@ -0,0 +1,173 @@
+package errors
+
+import (
+	stdcontext "context"
+	"sync"
+)
+
+// A Mode determines how a Group handles errors.
+type Mode int
+
+// Modes for a Group.
+const (
+	CollectAll Mode = iota // wait for all functions, and report all errors
+	FirstError             // cancel the group on the first error, and report it
+)
+
+// A Group runs functions in goroutines and reports the errors they return.
+// It is similar to the Group in package golang.org/x/sync/errgroup, except
+// that by default a Group reports every error, not just the first.
+//
+// The errors are tagged with the group's key/value pairs, and a function that
+// panics is reported as an error (see Recover). Each function can be tagged
+// with its own key/value pairs using the With method:
+//  g, ctx := errors.NewGroup(ctx)
+//  for i, item := range items {
+//      item := item
+//      g.With("worker", i).Go(func() error {
+//          return process(ctx, item)
+//      })
+//  }
+//  if err := g.Wait(); err != nil {
+//      return errors.Wrap(err, "cannot process items")
+//  }
+//
+// A Group must be created using NewGroup.
+type Group struct {
+	ctx   context
+	state *groupState
+}
+
+// groupState is the state shared by a group and the groups
+// derived from it using With.
+type groupState struct {
+	wg     sync.WaitGroup
+	cancel stdcontext.CancelCauseFunc
+	sem    chan struct{}
+
+	mu    sync.Mutex
+	mode  Mode
+	errs  []error
+	count int
+	first bool // first error has been recorded
+}
+
+// NewGroup returns a new group, and a context derived from ctx that is
+// canceled when Wait returns, or in FirstError mode, when the first
+// function returns an error. The group's key/value pairs are those of
+// the error context returned by FromContext(ctx).
+func NewGroup(ctx stdcontext.Context) (*Group, stdcontext.Context) {
+	errctx := FromContext(ctx).(context)
+	ctx, cancel := stdcontext.WithCancelCause(ctx)
+	g := &Group{
+		ctx:   errctx,
+		state: &groupState{cancel: cancel},
+	}
+	return g, ctx
+}
+
+// SetLimit limits the number of functions running at the same time to n.
+// A negative value means no limit, which is the default. SetLimit must not
+// be called while any functions in the group are running.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.state.sem = nil
+		return
+	}
+	g.state.sem = make(chan struct{}, n)
+}
+
+// SetMode sets the mode of the group. The default mode is CollectAll.
+// SetMode must not be called while any functions in the group are running.
+func (g *Group) SetMode(mode Mode) {
+	g.state.mu.Lock()
+	g.state.mode = mode
+	g.state.mu.Unlock()
+}
+
+// With returns a group with the key/value pairs attached to any error
+// returned by functions run by the group. The group returned shares its
+// state with g, so Wait waits for the functions run by either group.
+func (g *Group) With(keyvals ...interface{}) *Group {
+	return &Group{
+		ctx:   g.ctx.withKeyvals(keyvals),
+		state: g.state,
+	}
+}
+
+// Go runs fn in a new goroutine. If the group has a limit, Go blocks
+// until fn can run without exceeding the limit.
+func (g *Group) Go(fn func() error) {
+	s := g.state
+	if s.sem != nil {
+		s.sem <- struct{}{}
+	}
+	s.mu.Lock()
+	index := s.count
+	s.count++
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer func() {
+			if s.sem != nil {
+				<-s.sem
+			}
+			s.wg.Done()
+		}()
+		if err := g.run(fn); err != nil {
+			s.record(index, err)
+		}
+	}()
+}
+
+// run calls fn, converting a panic into an error, and attaches
+// the group's key/value pairs to any error.
+func (g *Group) run(fn func() error) (err error) {
+	defer g.ctx.Recover(&err)
+	if err = fn(); err != nil && len(g.ctx.keyvals) > 0 {
+		err = g.ctx.attachError(err, nil)
+	}
+	return err
+}
+
+// record records the error returned by the function with index.
+func (s *groupState) record(index int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mode == FirstError {
+		if s.first {
+			return
+		}
+		s.first = true
+		s.cancel(err)
+	}
+	for len(s.errs) <= index {
+		s.errs = append(s.errs, nil)
+	}
+	s.errs[index] = err
+}
+
+// Wait waits for all of the functions run by the group, and returns their
+// errors. In CollectAll mode, the errors are aggregated in the order that
+// the functions were passed to Go, in the same way as the Join function.
+// In FirstError mode, the first error returned is reported. If there are
+// no errors, Wait returns nil.
+func (g *Group) Wait() error {
+	s := g.state
+	s.wg.Wait()
+	s.cancel(nil)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mode == FirstError {
+		for _, err := range s.errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var ctx context
+	return ctx.join(s.errs, callers())
+}
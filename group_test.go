@@ -0,0 +1,120 @@
+package errors
+
+import (
+	stdcontext "context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupCollectAll(t *testing.T) {
+	g, ctx := NewGroup(NewContext(stdcontext.Background(), "id", 42))
+	for i := 0; i < 4; i++ {
+		i := i
+		g.With("worker", i).Go(func() error {
+			switch i {
+			case 1:
+				time.Sleep(10 * time.Millisecond)
+				return New("file locked")
+			case 2:
+				panic("boom")
+			case 3:
+				return io.EOF
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if ctx.Err() == nil {
+		t.Error("want context canceled after Wait")
+	}
+	errs := err.(interface{ Unwrap() []error }).Unwrap()
+	if len(errs) != 3 {
+		t.Fatalf("want 3 errors, got %d: %v", len(errs), err)
+	}
+	want := []string{
+		"file locked id=42 worker=1",
+		"recovered from panic id=42 worker=2 panic=true value=boom",
+		"EOF id=42 worker=3",
+	}
+	for i, err := range errs {
+		if got := err.Error(); got != want[i] {
+			t.Errorf("%d: want %q, got %q", i, want[i], got)
+		}
+	}
+	keyvals := err.(interface{ Keyvals() []interface{} }).Keyvals()
+	for i, worker := range []int{1, 2, 3} {
+		key := fmt.Sprintf("error.%d.worker", i)
+		if got, ok := lookupKeyval(keyvals, key); !ok || got != worker {
+			t.Errorf("%s: want %d, got %v", key, worker, got)
+		}
+	}
+	if !Is(err, io.EOF) {
+		t.Error("want Is to match io.EOF")
+	}
+}
+
+func TestGroupFirstError(t *testing.T) {
+	g, ctx := NewGroup(stdcontext.Background())
+	g.SetMode(FirstError)
+	g.Go(func() error {
+		return io.EOF
+	})
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err := g.Wait()
+	if err != io.EOF {
+		t.Errorf("want %v, got %v", io.EOF, err)
+	}
+	if got := stdcontext.Cause(ctx); got != io.EOF {
+		t.Errorf("want cause %v, got %v", io.EOF, got)
+	}
+}
+
+func TestGroupLimit(t *testing.T) {
+	g, _ := NewGroup(stdcontext.Background())
+	g.SetLimit(2)
+	var running, max int32
+	for i := 0; i < 10; i++ {
+		g.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if max > 2 {
+		t.Errorf("want at most 2 running, got %d", max)
+	}
+}
+
+func TestGroupPanicError(t *testing.T) {
+	g, _ := NewGroup(stdcontext.Background())
+	g.Go(func() error {
+		panic(io.ErrUnexpectedEOF)
+	})
+	err := g.Wait()
+	if !Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("want panic error in chain, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "recovered from panic") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
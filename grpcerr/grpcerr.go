@@ -0,0 +1,288 @@
+// Package grpcerr converts errors to and from gRPC status values.
+//
+// On the server, the kind of an error (see errors.KindOf) determines the
+// gRPC status code, and the key/value pairs attached to the error are sent
+// to the client as the metadata of an errdetails.ErrorInfo detail, with each
+// value encoded as JSON. On the client, the status is converted back to an
+// error with the same message, kind and key/value pairs.
+//
+// Only the messages and key/value pairs are sent to the client. Stack traces
+// are not sent.
+package grpcerr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jjeffery/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the value of the Domain field of the errdetails.ErrorInfo
+// sent with each status. It identifies the service that generated the error,
+// and is typically set during program initialization.
+var Domain = "github.com/jjeffery/errors"
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// kindCodes maps error kinds to gRPC codes.
+var kindCodes = map[errors.Kind]codes.Code{
+	errors.NotFound:         codes.NotFound,
+	errors.Conflict:         codes.AlreadyExists,
+	errors.Invalid:          codes.InvalidArgument,
+	errors.PermissionDenied: codes.PermissionDenied,
+	errors.Unavailable:      codes.Unavailable,
+	errors.Internal:         codes.Internal,
+	errors.Canceled:         codes.Canceled,
+	errors.DeadlineExceeded: codes.DeadlineExceeded,
+}
+
+// codeKinds maps gRPC codes to error kinds.
+var codeKinds = map[codes.Code]errors.Kind{
+	codes.NotFound:           errors.NotFound,
+	codes.AlreadyExists:      errors.Conflict,
+	codes.Aborted:            errors.Conflict,
+	codes.InvalidArgument:    errors.Invalid,
+	codes.FailedPrecondition: errors.Invalid,
+	codes.OutOfRange:         errors.Invalid,
+	codes.PermissionDenied:   errors.PermissionDenied,
+	codes.Unauthenticated:    errors.PermissionDenied,
+	codes.Unavailable:        errors.Unavailable,
+	codes.Internal:           errors.Internal,
+	codes.DataLoss:           errors.Internal,
+	codes.Canceled:           errors.Canceled,
+	codes.DeadlineExceeded:   errors.DeadlineExceeded,
+}
+
+// Code returns the gRPC code for err. If err is nil, Code returns codes.OK.
+//
+// The code is determined by the kind of the error. If the error does not
+// have a kind, the code is obtained from a codes.Code value attached to
+// the error, or from a gRPC status in the error chain. Otherwise Code
+// returns codes.Unknown.
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if code, ok := kindCodes[errors.KindOf(err)]; ok {
+		return code
+	}
+	if kv, ok := err.(keyvalser); ok {
+		keyvals := kv.Keyvals()
+		for i := 1; i < len(keyvals); i += 2 {
+			if code, ok := keyvals[i].(codes.Code); ok {
+				return code
+			}
+		}
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Code()
+	}
+	return codes.Unknown
+}
+
+// Kind returns the error kind for a gRPC code, or an empty kind if there
+// is no corresponding kind.
+func Kind(code codes.Code) errors.Kind {
+	return codeKinds[code]
+}
+
+// ToStatus converts err to a gRPC status. If err is nil, ToStatus returns
+// a status with codes.OK.
+//
+// The status message is the chain of messages in the error, without any
+// key/value pairs. The key/value pairs are sent in the metadata of an
+// errdetails.ErrorInfo, with each value encoded as JSON. Values that cannot
+// be represented in JSON are sent as a JSON string. The metadata is a map,
+// so if a key occurs more than once in the chain of errors, only the last
+// value returned by the Keyvals method of the error is sent. A kind attached
+// to the error is not included in the metadata, because it is represented
+// by the status code.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	code := Code(err)
+	kv, ok := err.(keyvalser)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			// already a gRPC status
+			return st
+		}
+		return status.New(code, err.Error())
+	}
+
+	var msgs []string
+	metadata := make(map[string]string)
+	keyvals := kv.Keyvals()
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		if errors.IsMessageKey(key) {
+			// message templates are not sent
+			if strings.HasSuffix(key, "msg") {
+				msgs = append(msgs, fmt.Sprint(value))
+			}
+			continue
+		}
+		switch value.(type) {
+		case errors.Kind, codes.Code:
+			// represented by the status code
+			continue
+		}
+		metadata[key] = encodeValue(value)
+	}
+
+	st := status.New(code, strings.Join(msgs, ": "))
+	info := &errdetails.ErrorInfo{
+		Reason:   reason(code),
+		Domain:   Domain,
+		Metadata: metadata,
+	}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+	return st
+}
+
+// ToError converts err to an error that can be returned by a gRPC service
+// method. If err is nil, ToError returns nil.
+func ToError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return ToStatus(err).Err()
+}
+
+// FromStatus converts a gRPC status to an error. If the status code is
+// codes.OK, FromStatus returns nil.
+//
+// The error has the status message, and the key/value pairs from the
+// metadata of any errdetails.ErrorInfo in the status details, sorted by key.
+// Each value is decoded from JSON: numbers are decoded as int if they are
+// integers that fit, otherwise as float64. A value that is not valid JSON is
+// used as a string.
+// If the status code corresponds to an error kind, the kind is attached to
+// the error with the key "kind". Otherwise the status code is attached to the
+// error with the key "code", so that Code returns the same code.
+func FromStatus(st *status.Status) errors.Error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	var keyvals []interface{}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+		var keys []string
+		for key := range info.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyvals = append(keyvals, key, decodeValue(info.Metadata[key]))
+		}
+	}
+	if kind := Kind(st.Code()); kind != "" {
+		keyvals = append(keyvals, "kind", kind)
+	} else {
+		keyvals = append(keyvals, "code", st.Code())
+	}
+	return errors.With(keyvals...).New(st.Message())
+}
+
+// FromError converts an error returned by a gRPC client to an error.
+// If err is nil, FromError returns nil. If err does not contain a gRPC
+// status, it is wrapped without a message.
+func FromError(err error) errors.Error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return errors.Wrap(err)
+	}
+	return FromStatus(st)
+}
+
+// UnaryServerInterceptor returns a server interceptor that converts errors
+// returned by service methods to gRPC status errors using ToError.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, ToError(err)
+		}
+		return resp, nil
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor that converts gRPC
+// status errors to errors using FromError.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return FromError(err)
+		}
+		return nil
+	}
+}
+
+// encodeValue encodes a value as JSON for the error metadata. An error
+// is encoded as its message, and a value that cannot be encoded is
+// encoded as a string containing its string representation.
+func encodeValue(value interface{}) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = `"PANIC"`
+		}
+	}()
+	switch v := value.(type) {
+	case json.Marshaler:
+		// includes errors from package errors
+	case error:
+		value = v.Error()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return string(data)
+}
+
+// decodeValue decodes a value in the error metadata. If s is not a
+// single JSON value, it is returned unchanged.
+func decodeValue(s string) interface{} {
+	value, err := errors.UnmarshalJSONValue([]byte(s))
+	if err != nil {
+		return s
+	}
+	return value
+}
+
+// reason returns the ErrorInfo reason for a code, for example
+// "NOT_FOUND" for codes.NotFound.
+func reason(code codes.Code) string {
+	var sb strings.Builder
+	for i, r := range code.String() {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(r)
+	}
+	return strings.ToUpper(sb.String())
+}
@@ -0,0 +1,175 @@
+package grpcerr_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/grpcerr"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{err: nil, want: codes.OK},
+		{err: io.EOF, want: codes.Unknown},
+		{err: errors.New("message"), want: codes.Unknown},
+		{err: errors.New("message").With("kind", errors.NotFound), want: codes.NotFound},
+		{err: errors.Wrap(errors.New("message").With("kind", errors.Invalid), "outer"), want: codes.InvalidArgument},
+		{err: errors.Wrap(context.DeadlineExceeded, "outer"), want: codes.DeadlineExceeded},
+		{err: errors.New("message").With("code", codes.ResourceExhausted), want: codes.ResourceExhausted},
+		{err: errors.Wrap(status.Error(codes.Unimplemented, "message"), "outer"), want: codes.Unimplemented},
+	}
+
+	for i, tt := range tests {
+		if got := grpcerr.Code(tt.err); got != tt.want {
+			t.Errorf("%d: want %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		err     error
+		code    codes.Code
+		text    string
+		keyvals []interface{}
+	}{
+		{
+			err:     errors.With("id", 42).New("document not found").With("kind", errors.NotFound),
+			code:    codes.NotFound,
+			text:    "document not found id=42 kind=not_found",
+			keyvals: []interface{}{"msg", "document not found", "id", 42, "kind", errors.NotFound},
+		},
+		{
+			err:     errors.With("attempt", 3).Wrap(errors.New("file locked").With("file", "testrun"), "retry failed"),
+			code:    codes.Unknown,
+			text:    "retry failed: file locked attempt=3 file=testrun code=Unknown",
+			keyvals: []interface{}{"msg", "retry failed: file locked", "attempt", 3, "file", "testrun", "code", codes.Unknown},
+		},
+		{
+			err:     errors.Wrap(errors.With("id", 1).New("{id} locked"), "retry failed"),
+			code:    codes.Unknown,
+			text:    "retry failed: 1 locked id=1 code=Unknown",
+			keyvals: []interface{}{"msg", "retry failed: 1 locked", "id", 1, "code", codes.Unknown},
+		},
+		{
+			err:     errors.Wrap(errors.New("inner").With("n", 1, "s", "42"), "outer").With("n", 2, "ok", true),
+			code:    codes.Unknown,
+			text:    "outer: inner n=1 ok=true s=42 code=Unknown",
+			keyvals: []interface{}{"msg", "outer: inner", "n", 1, "ok", true, "s", "42", "code", codes.Unknown},
+		},
+		{
+			err:     errors.Join(errors.New("file locked").With("worker", 0), io.EOF),
+			code:    codes.Unknown,
+			text:    `multiple errors error.0.msg="file locked" error.0.worker=0 error.1.msg=EOF code=Unknown`,
+			keyvals: []interface{}{"msg", "multiple errors", "error.0.msg", "file locked", "error.0.worker", 0, "error.1.msg", "EOF", "code", codes.Unknown},
+		},
+		{
+			err:     io.EOF,
+			code:    codes.Unknown,
+			text:    "EOF code=Unknown",
+			keyvals: []interface{}{"msg", "EOF", "code", codes.Unknown},
+		},
+	}
+
+	client := newTestClient(t)
+
+	for i, tt := range tests {
+		client.server.err = tt.err
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if got, want := grpcerr.Code(err), tt.code; got != want {
+			t.Errorf("%d: code: want %v, got %v", i, want, got)
+		}
+		if got, want := err.Error(), tt.text; got != want {
+			t.Errorf("%d: text: want %q, got %q", i, want, got)
+		}
+		keyvals := err.(interface{ Keyvals() []interface{} }).Keyvals()
+		if !reflect.DeepEqual(keyvals, tt.keyvals) {
+			t.Errorf("%d: keyvals: want %v, got %v", i, tt.keyvals, keyvals)
+		}
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	if err := grpcerr.FromStatus(status.New(codes.OK, "")); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if err := grpcerr.FromError(nil); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	if err := grpcerr.ToError(nil); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+	err := grpcerr.FromError(io.EOF)
+	if got, want := errors.Cause(err), io.EOF; got != want {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	st, _ := status.New(codes.NotFound, "not found").WithDetails(&errdetails.ErrorInfo{
+		Metadata: map[string]string{"ids": "[1,2.5]", "name": "not json", "obj": `{"a":1}`},
+	})
+	want := []interface{}{
+		"msg", "not found",
+		"ids", []interface{}{1, 2.5},
+		"name", "not json",
+		"obj", map[string]interface{}{"a": 1},
+		"kind", errors.NotFound,
+	}
+	if got := grpcerr.FromStatus(st).(interface{ Keyvals() []interface{} }).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (s *healthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+type testClient struct {
+	grpc_health_v1.HealthClient
+	server *healthServer
+}
+
+// newTestClient returns a client connected to a health server over
+// an in-process listener, with the interceptors from this package installed.
+func newTestClient(t *testing.T) *testClient {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(grpcerr.UnaryServerInterceptor()))
+	hs := &healthServer{}
+	grpc_health_v1.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(grpcerr.UnaryClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatalf("cannot dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{
+		HealthClient: grpc_health_v1.NewHealthClient(conn),
+		server:       hs,
+	}
+}
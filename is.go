@@ -0,0 +1,64 @@
+package errors
+
+import (
+	stderrors "errors"
+)
+
+// Is reports whether any error in err's chain matches target.
+//
+// Is is compatible with the Is function in the standard library
+// errors package, and simply calls that function. It is provided
+// here so that this package can continue to be used as a drop-in
+// replacement for the standard library package.
+//
+// Errors returned by the With method remember the error that they
+// were derived from, so a sentinel error continues to match after
+// key/value pairs have been attached to it:
+//  var ErrNotFound = errors.New("not found")
+//
+//  err := errors.Wrap(ErrNotFound.With("id", id), "cannot get document")
+//  if errors.Is(err, ErrNotFound) {
+//      // handle not found
+//  }
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, and if
+// so, sets target to that error value and returns true. Otherwise, it
+// returns false.
+//
+// As is compatible with the As function in the standard library
+// errors package, and simply calls that function.
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap returns the result of calling the Unwrap method on err, if
+// err's type contains an Unwrap method returning error. Otherwise,
+// Unwrap returns nil.
+//
+// Unwrap is compatible with the Unwrap function in the standard library
+// errors package, and simply calls that function.
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
+}
+
+// isDerived reports whether target is orig, or any of the errors
+// that orig was derived from by calling With.
+func isDerived(orig, target error) bool {
+	type deriver interface {
+		derivedFrom() error
+	}
+	for orig != nil {
+		if orig == target {
+			return true
+		}
+		d, ok := orig.(deriver)
+		if !ok {
+			break
+		}
+		orig = d.derivedFrom()
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// joinMessage is the message reported by Keyvals for an error
+// that aggregates several errors.
+const joinMessage = "multiple errors"
+
+// joinT represents an error that aggregates several errors,
+// and key/value pairs that apply to all of them.
+type joinT struct {
+	ctx         context
+	errs        []error
+	stack       *stack
+	orig        error  // error this was derived from using With, if any
+	fingerprint string // fingerprint restored by UnmarshalJSON, if any
+}
+
+// Error implements the error interface. The message for each error
+// is separated by a semicolon. If the error has key/value pairs of its
+// own, they are rendered first, so that they are not confused with the
+// key/value pairs of the last error.
+func (j *joinT) Error() string {
+	var buf bytes.Buffer
+	if len(j.ctx.keyvals) > 0 {
+		buf.WriteString(joinMessage)
+		j.ctx.writeToBuf(&buf)
+		buf.WriteString(": ")
+	}
+	for i, err := range j.errs {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(err.Error())
+	}
+	return buf.String()
+}
+
+// With returns an error with additional key/value pairs attached.
+// It implements the Error interface.
+func (j *joinT) With(keyvals ...interface{}) Error {
+	return &joinT{
+		ctx:   j.ctx.withKeyvals(keyvals),
+		errs:  j.errs,
+		stack: j.stack,
+		orig:  j,
+	}
+}
+
+// MarshalText implements the TextMarshaler interface.
+func (j *joinT) MarshalText() ([]byte, error) {
+	return []byte(j.Error()), nil
+}
+
+// StackTrace returns the stack trace recorded when the error was created.
+// It is compatible with the github.com/pkg/errors package.
+func (j *joinT) StackTrace() StackTrace {
+	return j.stack.StackTrace()
+}
+
+// Format implements the fmt.Formatter interface. The %+v verb
+// prints the key/value pairs and stack trace followed by each
+// of the errors, indented.
+func (j *joinT) Format(s fmt.State, verb rune) {
+	format(s, verb, j)
+}
+
+// formatLayer writes the key/value pairs and stack trace for this
+// error to s, followed by each of the errors formatted with %+v.
+func (j *joinT) formatLayer(s fmt.State) {
+	var buf bytes.Buffer
+	buf.WriteString(joinMessage)
+	j.ctx.writeToBuf(&buf)
+	s.Write(buf.Bytes())
+	j.stack.Format(s, 'v')
+	for i, err := range j.errs {
+		text := fmt.Sprintf("[%d] %+v", i, err)
+		fmt.Fprintf(s, "\n    %s", strings.Replace(text, "\n", "\n    ", -1))
+	}
+}
+
+// next returns nil, as the errors are formatted by formatLayer.
+func (j *joinT) next() error {
+	return nil
+}
+
+// Unwrap returns the errors. It is used by the Is and As functions in
+// the standard library errors package.
+//
+// A joinT does not implement the causer interface, because
+// it does not have a single cause.
+func (j *joinT) Unwrap() []error {
+	return j.errs
+}
+
+// Is reports whether the error was derived from target by
+// calling With, or whether target is the Kind of the error.
+// It is used by the standard library errors.Is function.
+func (j *joinT) Is(target error) bool {
+	return isDerived(j.orig, target) || isKind(j, target)
+}
+
+// derivedFrom returns the error that j was derived from
+// by calling With, or nil.
+func (j *joinT) derivedFrom() error {
+	return j.orig
+}
+
+// Keyvals returns the contents of the error
+// as an array of alternating keys and values.
+// The key/value pairs of each error follow, with
+// their keys prefixed by "error.0.", "error.1.", and
+// so on. An error that does not provide key/value pairs
+// is listed with its message only, as "error.0.msg".
+func (j *joinT) Keyvals() []interface{} {
+	return appendFingerprint(j.keyvals(), j)
+}
+
+func (j *joinT) keyvals() []interface{} {
+	var keyvals []interface{}
+	keyvals = append(keyvals, "msg", joinMessage)
+	keyvals = j.ctx.appendKeyvals(keyvals)
+	for i, err := range j.errs {
+		keyvals = appendJoinKeyvals(keyvals, i, err)
+	}
+	return keyvals
+}
+
+// appendJoinKeyvals appends the key/value pairs for the i'th joined
+// error to keyvals, with each key prefixed by "error.i.". If the error
+// does not implement keyvalser, its message is appended with the key
+// "error.i.msg".
+func appendJoinKeyvals(keyvals []interface{}, i int, err error) []interface{} {
+	prefix := "error." + strconv.Itoa(i) + "."
+	errKeyvals, ok := keyvalsOf(err)
+	if !ok {
+		return append(keyvals, prefix+"msg", err.Error())
+	}
+	for i, v := range errKeyvals {
+		if i%2 == 0 {
+			v = prefix + keyString(v)
+		}
+		keyvals = append(keyvals, v)
+	}
+	return keyvals
+}
+
+// isJoinKey reports whether key is a key used by Keyvals for
+// one of the errors in a join, such as "error.0.msg".
+func isJoinKey(key string) bool {
+	if !strings.HasPrefix(key, "error.") {
+		return false
+	}
+	key = key[len("error."):]
+	n := strings.IndexFunc(key, func(r rune) bool { return r < '0' || r > '9' })
+	return n > 0 && key[n] == '.'
+}
@@ -0,0 +1,204 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonWriter is implemented by each of the error types in this package.
+type jsonWriter interface {
+	error
+	writeJSON(obj *jsonObject)
+}
+
+// MarshalJSON implements the json.Marshaler interface. The error is
+// represented as a JSON object with its message and key/value pairs.
+// The fingerprint of the error is included, so that the error restored
+// by UnmarshalJSON has the same fingerprint. See Fingerprint.
+func (e *errorT) MarshalJSON() ([]byte, error) {
+	return marshalJSON(e), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The error is
+// represented as a JSON object with its message, key/value pairs and
+// a nested object for the cause.
+func (c *causeT) MarshalJSON() ([]byte, error) {
+	return marshalJSON(c), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The error is
+// represented as a JSON object with its key/value pairs and a nested
+// object for the cause.
+func (a *attachT) MarshalJSON() ([]byte, error) {
+	return marshalJSON(a), nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The error is
+// represented as a JSON object with its key/value pairs and an
+// array containing an object for each error.
+func (j *joinT) MarshalJSON() ([]byte, error) {
+	return marshalJSON(j), nil
+}
+
+func (e *errorT) writeJSON(obj *jsonObject) {
+	obj.field("msg")
+	writeJSONValue(obj.buf, e.message())
+	e.writeJSONTemplate(obj)
+	e.ctx.writeJSON(obj)
+}
+
+func (c *causeT) writeJSON(obj *jsonObject) {
+	c.errorT.writeJSON(obj)
+	obj.field("cause")
+	writeJSONError(obj.buf, c.cause)
+}
+
+func (a *attachT) writeJSON(obj *jsonObject) {
+	a.ctx.writeJSON(obj)
+	obj.field("cause")
+	writeJSONError(obj.buf, a.cause)
+}
+
+func (j *joinT) writeJSON(obj *jsonObject) {
+	j.ctx.writeJSON(obj)
+	obj.field("errors")
+	obj.buf.WriteByte('[')
+	for i, err := range j.errs {
+		if i > 0 {
+			obj.buf.WriteByte(',')
+		}
+		writeJSONError(obj.buf, err)
+	}
+	obj.buf.WriteByte(']')
+}
+
+// writeJSONTemplate writes the message template as a field named
+// "msg.template" of obj, if the message contains any placeholders with
+// matching keys.
+func (e *errorT) writeJSONTemplate(obj *jsonObject) {
+	if e.ctx.isTemplate(e.msg) {
+		obj.field("msg.template")
+		writeJSONValue(obj.buf, e.msg)
+	}
+}
+
+// writeJSON writes the context's key/value pairs as a field named
+// "keyvals" of obj. Nothing is written if there are no key/value pairs.
+// A key that appears more than once is written once, in the position
+// of its first appearance, with the last of its values.
+func (ctx context) writeJSON(obj *jsonObject) {
+	keyvals := ctx.appendKeyvals(nil)
+	if len(keyvals) == 0 {
+		return
+	}
+	var keys []string
+	last := make(map[string]int)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := keyString(keyvals[i])
+		if _, ok := last[key]; !ok {
+			keys = append(keys, key)
+		}
+		last[key] = i
+	}
+	obj.field("keyvals")
+	var kvobj jsonObject
+	kvobj.begin(obj.buf)
+	for _, key := range keys {
+		kvobj.field(key)
+		if i := last[key]; i+1 < len(keyvals) {
+			writeJSONValue(obj.buf, keyvals[i+1])
+		} else {
+			obj.buf.WriteString("null")
+		}
+	}
+	kvobj.end()
+}
+
+// marshalJSON returns the JSON representation of err, which is an
+// error from this package. The fingerprint of the error follows the
+// other fields.
+func marshalJSON(err jsonWriter) []byte {
+	var buf bytes.Buffer
+	var obj jsonObject
+	obj.begin(&buf)
+	err.writeJSON(&obj)
+	obj.field("fingerprint")
+	writeJSONValue(&buf, Fingerprint(err))
+	obj.end()
+	return buf.Bytes()
+}
+
+// writeJSONError writes a JSON object representing err. An error
+// from another package is represented by an object containing its
+// message.
+func writeJSONError(buf *bytes.Buffer, err error) {
+	var obj jsonObject
+	obj.begin(buf)
+	if w, ok := err.(jsonWriter); ok {
+		w.writeJSON(&obj)
+	} else {
+		obj.field("msg")
+		writeJSONValue(buf, err.Error())
+	}
+	obj.end()
+}
+
+// writeJSONValue writes the JSON representation of v. Values that
+// cannot be represented in JSON are written using the same
+// conventions as the key/value pairs in the error message.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	var b []byte
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				b = []byte(`"PANIC"`)
+			}
+		}()
+		switch value := v.(type) {
+		case json.Marshaler:
+			// includes errors from this package
+		case error:
+			v = value.Error()
+		}
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			b = []byte(`"ERROR"`)
+		}
+	}()
+	buf.Write(b)
+}
+
+// keyString returns the string representation of a key.
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// jsonObject writes the fields of a JSON object to a buffer, in order.
+type jsonObject struct {
+	buf    *bytes.Buffer
+	fields int
+}
+
+func (obj *jsonObject) begin(buf *bytes.Buffer) {
+	obj.buf = buf
+	obj.buf.WriteByte('{')
+}
+
+// field writes the name of the next field. The caller is
+// responsible for writing the value.
+func (obj *jsonObject) field(name string) {
+	if obj.fields > 0 {
+		obj.buf.WriteByte(',')
+	}
+	writeJSONValue(obj.buf, name)
+	obj.buf.WriteByte(':')
+	obj.fields++
+}
+
+func (obj *jsonObject) end() {
+	obj.buf.WriteByte('}')
+}
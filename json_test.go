@@ -0,0 +1,188 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{
+			err:  New("error message"),
+			want: `{"msg":"error message"}`,
+		},
+		{
+			err:  New("error message").With("a", 1, "b", "two words"),
+			want: `{"msg":"error message","keyvals":{"a":1,"b":"two words"}}`,
+		},
+		{
+			err:  New("error message").With("a", 1, "b", 2).With("a", 3),
+			want: `{"msg":"error message","keyvals":{"a":3,"b":2}}`,
+		},
+		{
+			err:  Wrap(io.EOF, "error message").With("a", 1),
+			want: `{"msg":"error message","keyvals":{"a":1},"cause":{"msg":"EOF"}}`,
+		},
+		{
+			err:  Wrap(io.EOF).With("c", 3),
+			want: `{"keyvals":{"c":3},"cause":{"msg":"EOF"}}`,
+		},
+		{
+			err: With("attempt", 3).Wrap(New("file locked").With("file", "testrun"), "retry failed"),
+			want: `{"msg":"retry failed","keyvals":{"attempt":3},` +
+				`"cause":{"msg":"file locked","keyvals":{"file":"testrun"}}}`,
+		},
+		{
+			err:  Join(New("first"), io.EOF).With("op", "close"),
+			want: `{"keyvals":{"op":"close"},"errors":[{"msg":"first"},{"msg":"EOF"}]}`,
+		},
+		{
+			err: New("msg").With(
+				"t", time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC),
+				"e", fmt.Errorf("this failed"),
+				"f", failingTextMarshaler(0),
+				"p", panicingStringer("no"),
+				"c", make(chan int),
+				1, nil,
+				"odd",
+			),
+			want: `{"msg":"msg","keyvals":{"t":"2018-01-02T03:04:05Z","e":"this failed",` +
+				`"f":"ERROR","p":"no","c":"ERROR","1":null,"odd":null}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		b, err := json.Marshal(tt.err)
+		if err != nil {
+			t.Errorf("%d: want no error, got %v", i, err)
+			continue
+		}
+		if got, want := string(b), withFingerprint(tt.want, tt.err); got != want {
+			t.Errorf("%d: want %s, got %s", i, want, got)
+		}
+	}
+}
+
+// withFingerprint returns the JSON object in data, with the
+// fingerprint of err added as the last field.
+func withFingerprint(data string, err error) string {
+	return strings.TrimSuffix(data, "}") + fmt.Sprintf(`,"fingerprint":%q}`, Fingerprint(err))
+}
+
+var errJSONNotFound = New("json not found")
+
+func TestUnmarshalJSON(t *testing.T) {
+	Register(errJSONNotFound)
+	Register(io.ErrUnexpectedEOF)
+
+	tests := []struct {
+		err error
+	}{
+		{err: New("error message")},
+		{err: New("error message").With("a", 1, "b", "two words", "c", 1.5, "d", true, "e", nil)},
+		{err: Wrap(io.EOF, "error message").With("a", 1)},
+		{err: Wrap(io.EOF).With("c", 3)},
+		{err: With("attempt", 3).Wrap(New("file locked").With("file", "testrun"), "retry failed")},
+		{err: Join(New("first"), Wrap(io.EOF, "second")).With("op", "close")},
+		{err: Wrap(errJSONNotFound.With("id", 42), "cannot get")},
+		{err: errJSONNotFound},
+		{err: Wrap(io.ErrUnexpectedEOF, "cannot read")},
+	}
+
+	type keyvalser interface {
+		Keyvals() []interface{}
+	}
+
+	for i, tt := range tests {
+		data, err := json.Marshal(tt.err)
+		if err != nil {
+			t.Errorf("%d: want no error, got %v", i, err)
+			continue
+		}
+		got, err := UnmarshalJSON(data)
+		if err != nil {
+			t.Errorf("%d: want no error, got %v", i, err)
+			continue
+		}
+		if want, got := tt.err.Error(), got.Error(); want != got {
+			t.Errorf("%d: Error: want %q, got %q", i, want, got)
+		}
+		if want, got := tt.err.(keyvalser).Keyvals(), got.(keyvalser).Keyvals(); fmt.Sprint(want) != fmt.Sprint(got) {
+			t.Errorf("%d: Keyvals: want %v, got %v", i, want, got)
+		}
+		if want, got := Cause(tt.err).Error(), Cause(got).Error(); want != got {
+			t.Errorf("%d: Cause: want %q, got %q", i, want, got)
+		}
+		if want, got := Fingerprint(tt.err), Fingerprint(got); want != got {
+			t.Errorf("%d: Fingerprint: want %q, got %q", i, want, got)
+		}
+		if data2, _ := json.Marshal(got); string(data) != string(data2) {
+			t.Errorf("%d: want %s, got %s", i, data, data2)
+		}
+	}
+
+	got, err := UnmarshalJSON([]byte(`{"msg":"cannot get","cause":{"msg":"json not found","keyvals":{"id":42}}}`))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if !Is(got, errJSONNotFound) {
+		t.Errorf("Is: want registered error to match")
+	}
+	got, err = UnmarshalJSON([]byte(`{"msg":"cannot read","cause":{"msg":"unexpected EOF"}}`))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if Cause(got) != io.ErrUnexpectedEOF {
+		t.Errorf("Cause: want io.ErrUnexpectedEOF, got %v", Cause(got))
+	}
+	got, err = UnmarshalJSON([]byte(`{"msg":"unexpected EOF","keyvals":{"n":1}}`))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if !Is(got, io.ErrUnexpectedEOF) {
+		t.Errorf("Is: want registered error to match")
+	}
+
+	for _, data := range []string{``, `[]`, `{}`, `{"msg":"x","keyvals":[]}`, `{"cause":[]}`} {
+		if _, err := UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("%s: want error, got nil", data)
+		}
+	}
+}
+
+func TestUnmarshalJSONValue(t *testing.T) {
+	tests := []struct {
+		data string
+		want interface{}
+	}{
+		{data: `42`, want: 42},
+		{data: `1.5`, want: 1.5},
+		{data: `"text"`, want: "text"},
+		{data: `[1,"a"]`, want: []interface{}{1, "a"}},
+		{data: `{"n":1}`, want: map[string]interface{}{"n": 1}},
+	}
+	for i, tt := range tests {
+		got, err := UnmarshalJSONValue([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: want %#v, got %#v", i, tt.want, got)
+		}
+	}
+
+	for _, data := range []string{``, `not json`, `1 2`} {
+		if _, err := UnmarshalJSONValue([]byte(data)); err == nil {
+			t.Errorf("%q: want error, got nil", data)
+		}
+	}
+}
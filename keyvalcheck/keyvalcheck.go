@@ -0,0 +1,345 @@
+// Package keyvalcheck defines an analyzer that reports misuse of the
+// key/value pairs passed to package github.com/jjeffery/errors.
+//
+// The analyzer reports:
+//
+//  - calls to With with an odd number of key/value arguments
+//  - keys that are not strings
+//  - errors passed as values, which should be wrapped instead
+//  - an "errors" variable created by errors.With that is never used
+//    to create an error
+//  - calls to Wrap whose message duplicates the message of the cause
+//
+// The analyzer can be run using the keyvalcheck command:
+//  go vet -vettool=$(which keyvalcheck) ./...
+package keyvalcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const errorsPath = "github.com/jjeffery/errors"
+
+// Analyzer reports misuse of key/value pairs in package github.com/jjeffery/errors.
+var Analyzer = &analysis.Analyzer{
+	Name:     "keyvalcheck",
+	Doc:      "check key/value pairs passed to github.com/jjeffery/errors",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// keyvalFuncs maps the names of functions and methods in the errors
+// package that accept key/value pairs to the index of the first key.
+var keyvalFuncs = map[string]int{
+	"With":       0,
+	"NewContext": 1,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn := errorsFunc(pass, call)
+		if fn == nil {
+			return
+		}
+		if start, ok := keyvalFuncs[fn.Name()]; ok {
+			checkKeyvals(pass, call, fn, start)
+		}
+		if fn.Name() == "Wrap" {
+			checkWrap(pass, call)
+		}
+	})
+
+	checkUnusedContexts(pass, insp)
+	return nil, nil
+}
+
+// errorsFunc returns the function or method in the errors package called by
+// call, or nil if call does not call a function in the errors package.
+func errorsFunc(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != errorsPath {
+		return nil
+	}
+	return fn
+}
+
+// checkKeyvals checks the key/value arguments of a call, starting at index start.
+func checkKeyvals(pass *analysis.Pass, call *ast.CallExpr, fn *types.Func, start int) {
+	if call.Ellipsis.IsValid() || len(call.Args) < start {
+		return
+	}
+	args := call.Args[start:]
+	if len(args)%2 != 0 {
+		pass.Reportf(args[len(args)-1].Pos(), "odd number of key/value arguments to %s: missing value for key %s",
+			fn.Name(), types.ExprString(args[len(args)-1]))
+	}
+	for i, arg := range args {
+		tv, ok := pass.TypesInfo.Types[arg]
+		if !ok {
+			continue
+		}
+		if i%2 == 0 {
+			if !isString(tv.Type) {
+				pass.Reportf(arg.Pos(), "key %s passed to %s is not a string", types.ExprString(arg), fn.Name())
+			}
+			continue
+		}
+		if isError(tv.Type) && !isErrorsType(tv.Type) {
+			pass.Reportf(arg.Pos(), "error %s passed as a value to %s: use Wrap to keep it as the cause",
+				types.ExprString(arg), fn.Name())
+		}
+	}
+}
+
+// checkWrap reports a call to Wrap whose message is the same as the message
+// of the cause, when the message of the cause can be determined statically.
+func checkWrap(pass *analysis.Pass, call *ast.CallExpr) {
+	if call.Ellipsis.IsValid() || len(call.Args) < 2 {
+		return
+	}
+	var msgs []string
+	for _, arg := range call.Args[1:] {
+		s, ok := constString(pass, arg)
+		if !ok {
+			return
+		}
+		if s != "" {
+			msgs = append(msgs, s)
+		}
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	msg := strings.Join(msgs, ": ")
+	if causeMsg, ok := causeMessage(pass, call.Args[0], 0); ok && causeMsg == msg {
+		pass.Reportf(call.Args[1].Pos(), "Wrap message %q duplicates the message of the cause", msg)
+	}
+}
+
+// causeMessage returns the message of the error created by expr, if it can
+// be determined statically. Errors created by errors.New, fmt.Errorf with no
+// arguments, and package-level variables initialized by these functions
+// are recognised.
+func causeMessage(pass *analysis.Pass, expr ast.Expr, depth int) (string, bool) {
+	if depth > 5 {
+		return "", false
+	}
+	switch e := astutil.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		var id *ast.Ident
+		switch fun := astutil.Unparen(e.Fun).(type) {
+		case *ast.Ident:
+			id = fun
+		case *ast.SelectorExpr:
+			if pass.TypesInfo.Selections[fun] != nil {
+				// method call, such as errors.New("msg").With("k", "v")
+				if fun.Sel.Name == "With" {
+					return causeMessage(pass, fun.X, depth+1)
+				}
+				return "", false
+			}
+			id = fun.Sel
+		default:
+			return "", false
+		}
+		fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+		if !ok || fn.Pkg() == nil {
+			return "", false
+		}
+		switch fn.Pkg().Path() + "." + fn.Name() {
+		case errorsPath + ".New", "errors.New":
+		case "fmt.Errorf":
+			if len(e.Args) != 1 {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+		if len(e.Args) < 1 {
+			return "", false
+		}
+		return constString(pass, e.Args[0])
+	case *ast.Ident:
+		v, ok := pass.TypesInfo.Uses[e].(*types.Var)
+		if !ok || v.Parent() != pass.Pkg.Scope() {
+			return "", false
+		}
+		if init := packageVarInit(pass, v); init != nil {
+			return causeMessage(pass, init, depth+1)
+		}
+	}
+	return "", false
+}
+
+// packageVarInit returns the initializer of a package-level variable.
+func packageVarInit(pass *analysis.Pass, v *types.Var) ast.Expr {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) != len(vs.Names) {
+					continue
+				}
+				for i, name := range vs.Names {
+					if pass.TypesInfo.Defs[name] == v {
+						return vs.Values[i]
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnusedContexts reports variables named "errors" that are defined by
+// calling errors.With, but are only ever used to call With again.
+func checkUnusedContexts(pass *analysis.Pass, insp *inspector.Inspector) {
+	defs := make(map[types.Object]*ast.Ident)
+	insp.Preorder([]ast.Node{(*ast.AssignStmt)(nil)}, func(n ast.Node) {
+		assign := n.(*ast.AssignStmt)
+		if assign.Tok != token.DEFINE || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+			return
+		}
+		id, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || id.Name != "errors" {
+			return
+		}
+		call, ok := astutil.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if fn := errorsFunc(pass, call); fn == nil || fn.Name() != "With" {
+			return
+		}
+		if obj := pass.TypesInfo.Defs[id]; obj != nil {
+			defs[obj] = id
+		}
+	})
+	if len(defs) == 0 {
+		return
+	}
+
+	used := make(map[types.Object]bool)
+	insp.WithStack([]ast.Node{(*ast.Ident)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		id := n.(*ast.Ident)
+		obj := pass.TypesInfo.Uses[id]
+		if _, ok := defs[obj]; !ok || used[obj] {
+			return true
+		}
+		if !isAssigned(id, stack) && !isReassignedWith(pass, obj, stack) {
+			used[obj] = true
+		}
+		return true
+	})
+
+	for obj, id := range defs {
+		if !used[obj] {
+			pass.Reportf(id.Pos(), "errors context is never used to create an error")
+		}
+	}
+}
+
+// isAssigned reports whether id is on the left hand side of an assignment.
+func isAssigned(id *ast.Ident, stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	assign, ok := stack[len(stack)-2].(*ast.AssignStmt)
+	if !ok {
+		return false
+	}
+	for _, lhs := range assign.Lhs {
+		if lhs == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isReassignedWith reports whether the identifier at the top of the stack
+// is used in a statement of the form "errors = errors.With(...)".
+func isReassignedWith(pass *analysis.Pass, obj types.Object, stack []ast.Node) bool {
+	n := len(stack)
+	if n < 4 {
+		return false
+	}
+	sel, ok := stack[n-2].(*ast.SelectorExpr)
+	if !ok || sel.X != stack[n-1] || sel.Sel.Name != "With" {
+		return false
+	}
+	call, ok := stack[n-3].(*ast.CallExpr)
+	if !ok || call.Fun != sel {
+		return false
+	}
+	assign, ok := stack[n-4].(*ast.AssignStmt)
+	if !ok || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 || assign.Rhs[0] != call {
+		return false
+	}
+	lhs, ok := assign.Lhs[0].(*ast.Ident)
+	return ok && pass.TypesInfo.ObjectOf(lhs) == obj
+}
+
+// constString returns the value of a constant string expression.
+func constString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// isString reports whether t is a string type.
+func isString(t types.Type) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// isError reports whether t is a concrete type or a non-empty
+// interface type that implements error.
+func isError(t types.Type) bool {
+	if iface, ok := t.Underlying().(*types.Interface); ok && iface.Empty() {
+		return false
+	}
+	return types.Implements(t, errorType) || types.Implements(types.NewPointer(t), errorType)
+}
+
+// isErrorsType reports whether t is a named type declared in the errors
+// package, such as errors.Kind, which are intended to be used as values.
+func isErrorsType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == errorsPath && obj.Name() == "Kind"
+}
@@ -0,0 +1,12 @@
+package keyvalcheck_test
+
+import (
+	"testing"
+
+	"github.com/jjeffery/errors/keyvalcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), keyvalcheck.Analyzer, "a")
+}
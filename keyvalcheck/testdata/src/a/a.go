@@ -0,0 +1,49 @@
+package a
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/jjeffery/errors"
+)
+
+var errLocked = errors.New("file locked")
+
+var errClosed = stderrors.New("file closed")
+
+type key string
+
+func keyvals(file string, line int, err error) {
+	errors.With("file", file, "line")      // want `odd number of key/value arguments to With: missing value for key "line"`
+	errors.New("x").With("file")           // want `odd number of key/value arguments to With: missing value for key "file"`
+	errors.With("a", 1).With("file", file) // ok
+	errors.With(line, file)                // want `key line passed to With is not a string`
+	errors.With(key("file"), file)         // ok
+	errors.With("err", err)                // want `error err passed as a value to With: use Wrap to keep it as the cause`
+	errors.With("kind", errors.NotFound)   // ok
+	errors.NewContext(nil, "id")           // want `odd number of key/value arguments to NewContext: missing value for key "id"`
+
+	kvs := []interface{}{"file", file}
+	errors.With(kvs...) // ok
+}
+
+func wrap(err error) {
+	errors.Wrap(errLocked, "file locked")                    // want `Wrap message "file locked" duplicates the message of the cause`
+	errors.Wrap(errClosed, "file closed")                    // want `Wrap message "file closed" duplicates the message of the cause`
+	errors.Wrap(errors.New("locked").With("a", 1), "locked") // want `Wrap message "locked" duplicates the message of the cause`
+	errors.With("a", 1).Wrap(fmt.Errorf("closed"), "closed") // want `Wrap message "closed" duplicates the message of the cause`
+	errors.Wrap(errLocked, "cannot open")                    // ok
+	errors.Wrap(err, "file locked")                          // ok
+	errors.Wrap(fmt.Errorf("closed %d", 1), "closed %d")     // ok
+}
+
+func unused(file string) {
+	errors := errors.With("file", file) // want `errors context is never used to create an error`
+	errors = errors.With("line", 1)
+}
+
+func used(file string) error {
+	errors := errors.With("file", file)
+	errors = errors.With("line", 1)
+	return errors.New("file locked")
+}
@@ -0,0 +1,24 @@
+// Package errors is a minimal stub of github.com/jjeffery/errors for testing.
+package errors
+
+type Error interface {
+	Error() string
+	With(keyvals ...interface{}) Error
+}
+
+type Context interface {
+	With(keyvals ...interface{}) Context
+	New(message string) Error
+	Wrap(err error, message ...string) Error
+}
+
+type Kind string
+
+func (k Kind) Error() string { return string(k) }
+
+const NotFound Kind = "not_found"
+
+func New(message string) Error                                       { return nil }
+func Wrap(err error, message ...string) Error                        { return nil }
+func With(keyvals ...interface{}) Context                            { return nil }
+func NewContext(ctx interface{}, keyvals ...interface{}) interface{} { return nil }
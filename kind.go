@@ -0,0 +1,124 @@
+package errors
+
+import (
+	stdcontext "context"
+)
+
+// A Kind classifies an error. A Kind is attached to an error as the
+// value of a key/value pair, conventionally with the key "kind":
+//  return errors.With("kind", errors.NotFound).New("document does not exist")
+//
+//  // ... or ...
+//
+//  return errors.Wrap(err, "cannot get document").With("kind", errors.NotFound)
+//
+// A Kind is also an error, so it can be compared with an error using
+// the Is function:
+//  if errors.Is(err, errors.NotFound) {
+//      // handle not found
+//  }
+type Kind string
+
+// Kinds of error.
+const (
+	NotFound         Kind = "not_found"         // requested entity does not exist
+	Conflict         Kind = "conflict"          // conflicts with the current state
+	Invalid          Kind = "invalid"           // invalid request or argument
+	PermissionDenied Kind = "permission_denied" // caller does not have permission
+	Unavailable      Kind = "unavailable"       // service is currently unavailable
+	Internal         Kind = "internal"          // internal error
+	Canceled         Kind = "canceled"          // operation was canceled
+	DeadlineExceeded Kind = "deadline_exceeded" // deadline expired before completion
+)
+
+// Error implements the error interface.
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// KindOf returns the kind of err, or an empty Kind if err has not been
+// classified.
+//
+// KindOf examines each error in the chain, starting with err and
+// following the Unwrap and Cause methods, and returns the first kind
+// found. Because the outermost error is examined first, a kind attached
+// when wrapping an error takes precedence over the kind of its cause.
+// If more than one kind is attached to the same error, the last one
+// attached is used.
+//
+// The errors context.Canceled and context.DeadlineExceeded have the
+// kinds Canceled and DeadlineExceeded respectively. For an error that
+// aggregates several errors (see Join), the kind of the first error
+// that has a kind is returned.
+func KindOf(err error) Kind {
+	type kinder interface {
+		kind() Kind
+	}
+	type causer interface {
+		Cause() error
+	}
+
+	for err != nil {
+		if k, ok := err.(Kind); ok {
+			return k
+		}
+		switch err {
+		case stdcontext.Canceled:
+			return Canceled
+		case stdcontext.DeadlineExceeded:
+			return DeadlineExceeded
+		}
+		if k, ok := err.(kinder); ok {
+			if kind := k.kind(); kind != "" {
+				return kind
+			}
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if kind := KindOf(err); kind != "" {
+					return kind
+				}
+			}
+			return ""
+		case causer:
+			err = e.Cause()
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// kind returns the last kind in the context's key/value pairs.
+// Lazily evaluated values are evaluated, so that a kind returned
+// by the function passed to Lazy is found.
+func (ctx context) kind() Kind {
+	// values are at odd indexes
+	i := len(ctx.keyvals) - 1
+	if i%2 == 0 {
+		i--
+	}
+	for ; i > 0; i -= 2 {
+		v := ctx.keyvals[i]
+		if l, ok := v.(*lazy); ok {
+			v = l.value()
+		}
+		if k, ok := v.(Kind); ok {
+			return k
+		}
+	}
+	return ""
+}
+
+func (e *errorT) kind() Kind  { return e.ctx.kind() }
+func (a *attachT) kind() Kind { return a.ctx.kind() }
+func (j *joinT) kind() Kind   { return j.ctx.kind() }
+
+// isKind reports whether target is a Kind, and err has that kind.
+func isKind(err, target error) bool {
+	k, ok := target.(Kind)
+	return ok && KindOf(err) == k
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Kind
+	}{
+		{err: nil, want: ""},
+		{err: io.EOF, want: ""},
+		{err: New("message"), want: ""},
+		{err: NotFound, want: NotFound},
+		{err: New("message").With("kind", NotFound), want: NotFound},
+		{err: With("kind", Conflict).New("message"), want: Conflict},
+		{err: With("kind", Conflict).New("message").With("kind", Invalid), want: Invalid},
+		{err: Wrap(New("message").With("kind", NotFound), "outer"), want: NotFound},
+		{err: Wrap(New("message").With("kind", NotFound), "outer").With("kind", Internal), want: Internal},
+		{err: Wrap(New("message").With("kind", NotFound)).With("id", 1), want: NotFound},
+		{err: Wrap(PermissionDenied, "outer"), want: PermissionDenied},
+		{err: fmt.Errorf("outer: %w", New("message").With("kind", Unavailable)), want: Unavailable},
+		{err: Wrap(stdcontext.Canceled, "outer"), want: Canceled},
+		{err: Wrap(stdcontext.DeadlineExceeded, "outer"), want: DeadlineExceeded},
+		{err: Join(io.EOF, New("message").With("kind", NotFound)), want: NotFound},
+		{err: Join(io.EOF, io.EOF), want: ""},
+		{err: Join(io.EOF, New("message").With("kind", NotFound)).With("kind", Invalid), want: Invalid},
+		{err: New("message").With("kind", NotFound, "odd"), want: NotFound},
+	}
+
+	for i, tt := range tests {
+		if got := KindOf(tt.err); got != tt.want {
+			t.Errorf("%d: want %q, got %q", i, tt.want, got)
+		}
+	}
+}
+
+func TestIsKind(t *testing.T) {
+	tests := []struct {
+		err    error
+		target error
+		want   bool
+	}{
+		{err: New("message").With("kind", NotFound), target: NotFound, want: true},
+		{err: New("message").With("kind", NotFound), target: Conflict, want: false},
+		{err: Wrap(New("message").With("kind", NotFound), "outer"), target: NotFound, want: true},
+		{err: Wrap(New("message").With("kind", NotFound)).With("id", 1), target: NotFound, want: true},
+		{err: Wrap(stdcontext.Canceled, "outer"), target: Canceled, want: true},
+		{err: Wrap(stdcontext.Canceled, "outer"), target: stdcontext.Canceled, want: true},
+		{err: Join(io.EOF, New("message").With("kind", NotFound)), target: NotFound, want: true},
+		{err: New("message"), target: NotFound, want: false},
+	}
+
+	for i, tt := range tests {
+		if got := Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("%d: want %v, got %v", i, tt.want, got)
+		}
+	}
+
+	if got, want := New("message").With("kind", NotFound).Error(), "message kind=not_found"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestKindJSON(t *testing.T) {
+	data, err := json.Marshal(Wrap(New("message").With("kind", NotFound), "outer"))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	got, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if KindOf(got) != NotFound {
+		t.Errorf("want %q, got %q", NotFound, KindOf(got))
+	}
+}
@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Lazy returns a value that is evaluated by calling fn the first time the
+// error is rendered. The result is cached, so fn is called at most once,
+// even if the error is rendered concurrently by several goroutines.
+//
+// Lazy is useful for values that are expensive to compute, and are only
+// needed if the error is logged:
+//  return errors.Wrap(err, "cannot process request").With(
+//      "request", errors.Lazy(func() interface{} {
+//          return dumpRequest(req)
+//      }),
+//  )
+//
+// If fn panics, the value is the text "PANIC". The value of a key/value
+// pair that is redacted is not evaluated.
+func Lazy(fn func() interface{}) interface{} {
+	return &lazy{fn: fn}
+}
+
+// lazy is a value that is evaluated when first needed.
+type lazy struct {
+	once sync.Once
+	fn   func() interface{}
+	v    interface{}
+}
+
+// value returns the value, calling fn if it has not already been called.
+func (l *lazy) value() interface{} {
+	l.once.Do(func() {
+		defer func() {
+			if r := recover(); r != nil {
+				l.v = "PANIC"
+			}
+		}()
+		if l.fn != nil {
+			l.v = l.fn()
+		}
+		l.fn = nil
+	})
+	return l.v
+}
+
+// String implements fmt.Stringer.
+func (l *lazy) String() string {
+	return fmt.Sprint(l.value())
+}
+
+// LogValue implements slog.LogValuer.
+func (l *lazy) LogValue() slog.Value {
+	return slog.AnyValue(l.value())
+}
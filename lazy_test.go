@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	var calls int32
+	value := Lazy(func() interface{} {
+		atomic.AddInt32(&calls, 1)
+		return 42
+	})
+	err := New("message").With("n", value)
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Fatalf("want no calls before rendering, got %d", got)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, want := err.Error(), "message n=42"; got != want {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := err.(keyvalser).Keyvals(), []interface{}{"msg", "message", "n", 42}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got, _ := json.Marshal(err); string(got) != withFingerprint(`{"msg":"message","keyvals":{"n":42}}`, err) {
+		t.Errorf("unexpected JSON %s", got)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("want 1 call, got %d", got)
+	}
+
+	panics := New("message").With("p", Lazy(func() interface{} { panic("no") }))
+	if got, want := panics.Error(), "message p=PANIC"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	redacted := With().WithRedactPolicy(&RedactPolicy{Keys: []string{"password"}}).New("message").With(
+		"password", Lazy(func() interface{} {
+			t.Errorf("redacted value should not be evaluated")
+			return "hunter2"
+		}),
+	)
+	if got, want := redacted.Error(), "message password=REDACTED"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	kinded := Wrap(New("message").With("kind", Lazy(func() interface{} { return NotFound })), "outer")
+	if got, want := KindOf(kinded), NotFound; got != want {
+		t.Errorf("KindOf: want %q, got %q", want, got)
+	}
+	if !Is(kinded, NotFound) {
+		t.Errorf("Is: want match for %v", NotFound)
+	}
+}
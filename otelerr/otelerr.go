@@ -0,0 +1,208 @@
+// Package otelerr records errors on OpenTelemetry spans.
+//
+// An error is recorded as an exception event with the attributes described
+// by the OpenTelemetry semantic conventions for exceptions. The key/value
+// pairs attached to the error are added to the event as typed attributes.
+package otelerr
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jjeffery/errors"
+)
+
+// Attribute keys for exception events, as described by the
+// OpenTelemetry semantic conventions.
+const (
+	ExceptionEventName  = "exception"
+	ExceptionType       = attribute.Key("exception.type")
+	ExceptionMessage    = attribute.Key("exception.message")
+	ExceptionStacktrace = attribute.Key("exception.stacktrace")
+)
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// stackTracer is implemented by the errors in package
+// github.com/jjeffery/errors that record a stack trace.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// Options control how an error is recorded.
+type Options struct {
+	// Keys maps the keys of the key/value pairs attached to the error to
+	// attribute keys. A key that maps to an empty string is not recorded.
+	// Keys that are not in the map are recorded with Prefix prepended.
+	Keys map[string]string
+
+	// Prefix is prepended to the keys of the key/value pairs
+	// that are not in Keys.
+	Prefix string
+
+	// SetStatus causes the status of the span to be set to
+	// codes.Error, with the error message as the description.
+	SetStatus bool
+}
+
+// Record records err as an exception event on span, using the default
+// options. If err is nil, or the span is not recording, Record does nothing.
+func Record(span trace.Span, err error) {
+	var opts Options
+	opts.Record(span, err)
+}
+
+// Record records err as an exception event on span. If err is nil,
+// or the span is not recording, Record does nothing.
+//
+// The event has the exception.type, exception.message and (if the error
+// recorded a stack trace) exception.stacktrace attributes, followed by an
+// attribute for each key/value pair attached to the error.
+func (opts *Options) Record(span trace.Span, err error) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		ExceptionType.String(typeString(err)),
+		ExceptionMessage.String(err.Error()),
+	}
+	if _, ok := err.(stackTracer); ok {
+		attrs = append(attrs, ExceptionStacktrace.String(fmt.Sprintf("%+v", err)))
+	}
+	if kv, ok := err.(keyvalser); ok {
+		attrs = opts.appendAttrs(attrs, kv.Keyvals())
+	}
+	span.AddEvent(ExceptionEventName, trace.WithAttributes(attrs...))
+	if opts.SetStatus {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// appendAttrs appends an attribute for each key/value pair, except for
+// the messages, which are already recorded as the exception message.
+func (opts *Options) appendAttrs(attrs []attribute.KeyValue, keyvals []interface{}) []attribute.KeyValue {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if errors.IsMessageKey(key) {
+			continue
+		}
+		if mapped, ok := opts.Keys[key]; ok {
+			key = mapped
+		} else {
+			key = opts.Prefix + key
+		}
+		if key == "" {
+			continue
+		}
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		attrs = append(attrs, Attribute(key, value))
+	}
+	return attrs
+}
+
+// Attribute returns an attribute with a type appropriate for value.
+// Booleans, integers, floating point numbers, strings and slices of these
+// types have the corresponding attribute types. Other values, including
+// integers that do not fit in an int64, are converted to strings.
+func Attribute(key string, value interface{}) (kv attribute.KeyValue) {
+	k := attribute.Key(key)
+	defer func() {
+		if r := recover(); r != nil {
+			kv = k.String("PANIC")
+		}
+	}()
+	switch v := value.(type) {
+	case nil:
+		return k.String("<nil>")
+	case bool:
+		return k.Bool(v)
+	case string:
+		return k.String(v)
+	case int:
+		return k.Int(v)
+	case int8:
+		return k.Int64(int64(v))
+	case int16:
+		return k.Int64(int64(v))
+	case int32:
+		return k.Int64(int64(v))
+	case int64:
+		return k.Int64(v)
+	case uint8:
+		return k.Int64(int64(v))
+	case uint16:
+		return k.Int64(int64(v))
+	case uint32:
+		return k.Int64(int64(v))
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return k.Int64(int64(v))
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return k.Int64(int64(v))
+		}
+	case float32:
+		return k.Float64(float64(v))
+	case float64:
+		return k.Float64(v)
+	case []bool:
+		return k.BoolSlice(v)
+	case []string:
+		return k.StringSlice(v)
+	case []int:
+		return k.IntSlice(v)
+	case []int64:
+		return k.Int64Slice(v)
+	case []float64:
+		return k.Float64Slice(v)
+	case time.Duration:
+		return k.String(v.String())
+	case error:
+		return k.String(v.Error())
+	case fmt.Stringer:
+		return k.String(v.String())
+	}
+	return k.String(fmt.Sprint(value))
+}
+
+// typeString returns the name of the type of err, including its package path.
+func typeString(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr && t.Elem().Name() != "" {
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" || t.Name() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// ExtractSpan returns the trace ID and span ID of the span in ctx as the
+// key/value pairs "trace_id" and "span_id". It is an errors.Extractor,
+// so that errors created from errors.FromContext include the span:
+//  errors.RegisterExtractor(otelerr.ExtractSpan)
+func ExtractSpan(ctx context.Context) []interface{} {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []interface{}{
+		"trace_id", sc.TraceID().String(),
+		"span_id", sc.SpanID().String(),
+	}
+}
@@ -0,0 +1,136 @@
+package otelerr_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/otelerr"
+)
+
+func newTracer() (*tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return exporter, provider
+}
+
+func TestRecord(t *testing.T) {
+	exporter, provider := newTracer()
+	_, span := provider.Tracer("test").Start(context.Background(), "op")
+
+	err := errors.Wrap(errors.New("file locked").With("file", "testrun"), "retry failed").With(
+		"attempt", 3,
+		"ok", false,
+		"ratio", 0.5,
+		"timeout", time.Second,
+		"user", "bob",
+		"password", "secret",
+	)
+	opts := &otelerr.Options{
+		Keys:      map[string]string{"user": "enduser.id", "password": ""},
+		Prefix:    "error.",
+		SetStatus: true,
+	}
+	opts.Record(span, err)
+	otelerr.Record(span, nil)
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("want 1 span, got %d", len(spans))
+	}
+	if got, want := spans[0].Status.Code, codes.Error; got != want {
+		t.Errorf("want status %v, got %v", want, got)
+	}
+	events := spans[0].Events
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("want 1 exception event, got %v", events)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	var keys []attribute.Key
+	for _, kv := range events[0].Attributes {
+		attrs[kv.Key] = kv.Value
+		keys = append(keys, kv.Key)
+	}
+	wantKeys := []attribute.Key{
+		"exception.type",
+		"exception.message",
+		"exception.stacktrace",
+		"error.attempt",
+		"error.ok",
+		"error.ratio",
+		"error.timeout",
+		"enduser.id",
+		"error.file",
+	}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("want keys %v, got %v", wantKeys, keys)
+	}
+	if got, want := attrs["exception.type"].AsString(), "github.com/jjeffery/errors.causeT"; got != want {
+		t.Errorf("want type %q, got %q", want, got)
+	}
+	if got, want := attrs["exception.message"].AsString(), err.Error(); got != want {
+		t.Errorf("want message %q, got %q", want, got)
+	}
+	if got := attrs["exception.stacktrace"].AsString(); !strings.Contains(got, "otelerr_test.TestRecord") {
+		t.Errorf("want stack trace, got %q", got)
+	}
+	if got := attrs["error.attempt"]; got.Type() != attribute.INT64 || got.AsInt64() != 3 {
+		t.Errorf("unexpected attempt %v", got.Emit())
+	}
+	if got := attrs["error.ok"]; got.Type() != attribute.BOOL || got.AsBool() {
+		t.Errorf("unexpected ok %v", got.Emit())
+	}
+	if got := attrs["error.ratio"]; got.Type() != attribute.FLOAT64 || got.AsFloat64() != 0.5 {
+		t.Errorf("unexpected ratio %v", got.Emit())
+	}
+	if got := attrs["error.timeout"].AsString(); got != "1s" {
+		t.Errorf("unexpected timeout %q", got)
+	}
+	if got := attrs["enduser.id"].AsString(); got != "bob" {
+		t.Errorf("unexpected user %q", got)
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  attribute.KeyValue
+	}{
+		{value: nil, want: attribute.String("k", "<nil>")},
+		{value: int8(1), want: attribute.Int64("k", 1)},
+		{value: uint64(1 << 63), want: attribute.String("k", "9223372036854775808")},
+		{value: []string{"a", "b"}, want: attribute.StringSlice("k", []string{"a", "b"})},
+		{value: errors.NotFound, want: attribute.String("k", "not_found")},
+		{value: struct{ A int }{1}, want: attribute.String("k", "{1}")},
+	}
+	for i, tt := range tests {
+		if got := otelerr.Attribute("k", tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: want %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestExtractSpan(t *testing.T) {
+	_, provider := newTracer()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+
+	sc := span.SpanContext()
+	want := []interface{}{"trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String()}
+	if got := otelerr.ExtractSpan(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if got := otelerr.ExtractSpan(context.Background()); got != nil {
+		t.Errorf("want nil, got %v", got)
+	}
+}
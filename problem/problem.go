@@ -0,0 +1,341 @@
+// Package problem renders errors as HTTP problem details documents, as
+// described in RFC 9457 (which obsoletes RFC 7807), and parses problem
+// details documents back into errors.
+//
+// The problem document is derived from the outermost message and the kind of
+// the error (see errors.KindOf). Messages of wrapped errors are not included,
+// because they often contain internal details that should not be exposed to
+// clients. Key/value pairs attached to the error are only included if they are
+// selected using Options.Extensions.
+package problem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/jjeffery/errors"
+)
+
+// ContentType is the media type of a problem details document.
+const ContentType = "application/problem+json"
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// messager is implemented by the errors in package
+// github.com/jjeffery/errors that have a message of their own.
+type messager interface {
+	Message() string
+}
+
+// kindStatus maps error kinds to HTTP status codes.
+var kindStatus = map[errors.Kind]int{
+	errors.NotFound:         http.StatusNotFound,
+	errors.Conflict:         http.StatusConflict,
+	errors.Invalid:          http.StatusBadRequest,
+	errors.PermissionDenied: http.StatusForbidden,
+	errors.Unavailable:      http.StatusServiceUnavailable,
+	errors.Internal:         http.StatusInternalServerError,
+	errors.Canceled:         499, // client closed request
+	errors.DeadlineExceeded: http.StatusGatewayTimeout,
+}
+
+// statusKind maps HTTP status codes to error kinds.
+var statusKind = map[int]errors.Kind{
+	http.StatusBadRequest:          errors.Invalid,
+	http.StatusUnprocessableEntity: errors.Invalid,
+	http.StatusUnauthorized:        errors.PermissionDenied,
+	http.StatusForbidden:           errors.PermissionDenied,
+	http.StatusNotFound:            errors.NotFound,
+	http.StatusConflict:            errors.Conflict,
+	http.StatusPreconditionFailed:  errors.Conflict,
+	http.StatusInternalServerError: errors.Internal,
+	http.StatusBadGateway:          errors.Unavailable,
+	http.StatusServiceUnavailable:  errors.Unavailable,
+	http.StatusGatewayTimeout:      errors.DeadlineExceeded,
+	http.StatusRequestTimeout:      errors.DeadlineExceeded,
+	499:                            errors.Canceled,
+}
+
+// Problem is a problem details document.
+type Problem struct {
+	Type     string // URI reference identifying the problem type
+	Title    string // short summary of the problem type
+	Status   int    // HTTP status code
+	Detail   string // explanation specific to this occurrence
+	Instance string // URI reference identifying this occurrence
+
+	// Extensions contains additional members of the problem document.
+	Extensions map[string]interface{}
+}
+
+// Options control how an error is converted to a problem document.
+type Options struct {
+	// TypeBase is the base URI for the problem type. If TypeBase is not
+	// empty and the error has a kind, the problem type is TypeBase
+	// followed by the kind, for example "https://example.com/problems/not_found".
+	// Otherwise the problem type is omitted, which is equivalent to "about:blank".
+	TypeBase string
+
+	// Extensions lists the keys of the key/value pairs attached to the error
+	// that are included in the problem document as extension members.
+	Extensions []string
+}
+
+// New returns the problem document for err. If opts is nil, the
+// default options are used. The detail member is the message of err,
+// and is omitted if err does not have a message of its own, such as an
+// error from another package, or an error returned by Wrap without a
+// message.
+func New(err error, opts *Options) *Problem {
+	if opts == nil {
+		opts = &Options{}
+	}
+	kind := errors.KindOf(err)
+	status, ok := kindStatus[kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	p := &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if opts.TypeBase != "" && kind != "" {
+		p.Type = opts.TypeBase + string(kind)
+	}
+
+	if m, ok := err.(messager); ok {
+		p.Detail = m.Message()
+	}
+	kv, ok := err.(keyvalser)
+	if !ok {
+		return p
+	}
+	keyvals := kv.Keyvals()
+	for _, key := range opts.Extensions {
+		if isReserved(key) {
+			continue
+		}
+		for i := 0; i < len(keyvals)-1; i += 2 {
+			if keyvals[i] == key {
+				if p.Extensions == nil {
+					p.Extensions = make(map[string]interface{})
+				}
+				p.Extensions[key] = keyvals[i+1]
+				break
+			}
+		}
+	}
+	return p
+}
+
+// Write writes the problem document for err to w, with the status code
+// and content type set appropriately.
+func Write(w http.ResponseWriter, err error, opts *Options) error {
+	p := New(err, opts)
+	data, jsonErr := json.Marshal(p)
+	if jsonErr != nil {
+		return errors.Wrap(jsonErr, "cannot marshal problem")
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	_, writeErr := w.Write(data)
+	return writeErr
+}
+
+// MarshalJSON implements the json.Marshaler interface. Extension
+// members are written after the standard members, sorted by name.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	var n int
+	write := func(name string, value interface{}) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "cannot marshal problem member").With("name", name)
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		quoted, _ := json.Marshal(name)
+		buf.Write(quoted)
+		buf.WriteByte(':')
+		buf.Write(data)
+		return nil
+	}
+	standard := []struct {
+		name  string
+		value interface{}
+		omit  bool
+	}{
+		{"type", p.Type, p.Type == ""},
+		{"title", p.Title, p.Title == ""},
+		{"status", p.Status, p.Status == 0},
+		{"detail", p.Detail, p.Detail == ""},
+		{"instance", p.Instance, p.Instance == ""},
+	}
+	for _, m := range standard {
+		if !m.omit {
+			if err := write(m.name, m.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var names []string
+	for name := range p.Extensions {
+		if !isReserved(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := write(name, extensionValue(p.Extensions[name])); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Members
+// other than the standard members are stored in Extensions. Standard
+// members with the wrong type are ignored, as required by RFC 9457.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for name, raw := range members {
+		switch name {
+		case "type":
+			json.Unmarshal(raw, &p.Type)
+		case "title":
+			json.Unmarshal(raw, &p.Title)
+		case "status":
+			json.Unmarshal(raw, &p.Status)
+		case "detail":
+			json.Unmarshal(raw, &p.Detail)
+		case "instance":
+			json.Unmarshal(raw, &p.Instance)
+		default:
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]interface{})
+			}
+			p.Extensions[name] = value
+		}
+	}
+	return nil
+}
+
+// Parse reads a problem document from r.
+func Parse(r io.Reader) (*Problem, error) {
+	var p Problem
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, errors.Wrap(err, "cannot parse problem")
+	}
+	return &p, nil
+}
+
+// Err returns an error with the details of the problem. The message is the
+// problem detail, or the title if there is no detail. The extension members
+// are attached as key/value pairs sorted by name, followed by the problem
+// type and instance, if present. If the status corresponds to an error kind,
+// the kind is attached with the key "kind", otherwise the status is attached
+// with the key "status".
+func (p *Problem) Err() errors.Error {
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	if msg == "" {
+		msg = http.StatusText(p.Status)
+	}
+
+	var keyvals []interface{}
+	var names []string
+	for name := range p.Extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		keyvals = append(keyvals, name, p.Extensions[name])
+	}
+	if p.Type != "" && p.Type != "about:blank" {
+		keyvals = append(keyvals, "type", p.Type)
+	}
+	if p.Instance != "" {
+		keyvals = append(keyvals, "instance", p.Instance)
+	}
+	if kind, ok := statusKind[p.Status]; ok {
+		keyvals = append(keyvals, "kind", kind)
+	} else if p.Status != 0 {
+		keyvals = append(keyvals, "status", p.Status)
+	}
+	return errors.With(keyvals...).New(msg)
+}
+
+// FromResponse returns an error describing an unsuccessful HTTP response.
+// If the response status code is less than 400, FromResponse returns nil.
+//
+// If the response body is a problem document, the error is created
+// using Problem.Err. Otherwise the error is created from the response
+// status. FromResponse reads the response body, but does not close it.
+func FromResponse(resp *http.Response) errors.Error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == ContentType {
+		if p, err := Parse(resp.Body); err == nil {
+			if p.Status == 0 {
+				p.Status = resp.StatusCode
+			}
+			return p.Err()
+		}
+	}
+	p := &Problem{
+		Title:  http.StatusText(resp.StatusCode),
+		Status: resp.StatusCode,
+	}
+	return p.Err()
+}
+
+// isReserved reports whether name is one of the standard members
+// of a problem document.
+func isReserved(name string) bool {
+	switch name {
+	case "type", "title", "status", "detail", "instance":
+		return true
+	}
+	return false
+}
+
+// extensionValue returns a value that can be marshaled as JSON.
+// Errors are represented by their message, and values that cannot
+// be marshaled are represented by their string representation.
+func extensionValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Marshaler:
+		return v
+	case error:
+		return v.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
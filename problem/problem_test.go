@@ -0,0 +1,160 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/problem"
+)
+
+func TestNew(t *testing.T) {
+	opts := &problem.Options{
+		TypeBase:   "https://example.com/problems/",
+		Extensions: []string{"id", "status", "missing", "ch"},
+	}
+	tests := []struct {
+		err  error
+		opts *problem.Options
+		want string
+	}{
+		{
+			err:  io.EOF,
+			want: `{"title":"Internal Server Error","status":500}`,
+		},
+		{
+			err:  errors.Wrap(io.EOF, "cannot read document").With("id", 42),
+			want: `{"title":"Internal Server Error","status":500,"detail":"cannot read document"}`,
+		},
+		{
+			err:  errors.Wrap(errors.New("no such row").With("table", "docs")).With("id", 42),
+			want: `{"title":"Internal Server Error","status":500}`,
+		},
+		{
+			err:  errors.Wrap(io.EOF).With("id", 42),
+			want: `{"title":"Internal Server Error","status":500}`,
+		},
+		{
+			err:  errors.Wrap(errors.New("no such row").With("table", "docs"), "document not found").With("kind", errors.NotFound, "id", 42),
+			opts: opts,
+			want: `{"type":"https://example.com/problems/not_found","title":"Not Found","status":404,"detail":"document not found","id":42}`,
+		},
+		{
+			err:  errors.New("invalid name").With("kind", errors.Invalid, "status", 1, "ch", make(chan int)),
+			opts: &problem.Options{Extensions: []string{"status", "ch"}},
+			want: `{"title":"Bad Request","status":400,"detail":"invalid name","ch":"0x`,
+		},
+	}
+
+	for i, tt := range tests {
+		data, err := json.Marshal(problem.New(tt.err, tt.opts))
+		if err != nil {
+			t.Errorf("%d: want no error, got %v", i, err)
+			continue
+		}
+		if got := string(data); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%d: want %s, got %s", i, tt.want, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	opts := &problem.Options{
+		TypeBase:   "https://example.com/problems/",
+		Extensions: []string{"id", "name"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.URL.Path {
+		case "/not-found":
+			err = errors.With("id", 42, "name", "doc").New("document not found").With("kind", errors.NotFound)
+		case "/internal":
+			err = errors.Wrap(io.EOF, "database failure")
+		case "/teapot":
+			http.Error(w, "short and stout", http.StatusTeapot)
+			return
+		default:
+			w.Write([]byte("ok"))
+			return
+		}
+		problem.Write(w, err, opts)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path    string
+		text    string
+		keyvals []interface{}
+	}{
+		{
+			path:    "/not-found",
+			text:    `document not found id=42 name=doc type="https://example.com/problems/not_found" kind=not_found`,
+			keyvals: []interface{}{"msg", "document not found", "id", float64(42), "name", "doc", "type", "https://example.com/problems/not_found", "kind", errors.NotFound},
+		},
+		{
+			path:    "/internal",
+			text:    `database failure kind=internal`,
+			keyvals: []interface{}{"msg", "database failure", "kind", errors.Internal},
+		},
+		{
+			path:    "/teapot",
+			text:    `I'm a teapot status=418`,
+			keyvals: []interface{}{"msg", "I'm a teapot", "status", 418},
+		},
+	}
+
+	for i, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		err = problem.FromResponse(resp)
+		resp.Body.Close()
+		if err == nil {
+			t.Errorf("%d: want error, got nil", i)
+			continue
+		}
+		if got := err.Error(); got != tt.text {
+			t.Errorf("%d: want %q, got %q", i, tt.text, got)
+		}
+		keyvals := err.(interface{ Keyvals() []interface{} }).Keyvals()
+		if !reflect.DeepEqual(keyvals, tt.keyvals) {
+			t.Errorf("%d: want %v, got %v", i, tt.keyvals, keyvals)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := problem.FromResponse(resp); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := problem.Parse(strings.NewReader(`{"type":"about:blank","title":"Conflict","status":"409","balance":30,"accounts":["a","b"]}`))
+	if err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	want := &problem.Problem{
+		Type:  "about:blank",
+		Title: "Conflict",
+		Extensions: map[string]interface{}{
+			"balance":  float64(30),
+			"accounts": []interface{}{"a", "b"},
+		},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("want %+v, got %+v", want, p)
+	}
+	if _, err := problem.Parse(strings.NewReader(`[]`)); err == nil {
+		t.Errorf("want error, got nil")
+	}
+}
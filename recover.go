@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"runtime"
+	"strings"
+)
+
+// panicMessage is the message of an error created from a recovered panic.
+const panicMessage = "recovered from panic"
+
+// Recover recovers from a panic, and sets *errp to an error describing
+// the panic. It must be called directly by a deferred function call,
+// typically in a function with a named error result:
+//  func doSomething() (err error) {
+//      defer errors.Recover(&err)
+//
+//      // ... code that might panic ...
+//  }
+//
+// The error has the message "recovered from panic" and the key/value pair
+// "panic" with the value true. If the panic value is an error, it is the
+// cause of the error, otherwise the panic value is attached with the key
+// "value". The error records the stack trace of the goroutine at the point
+// of the panic.
+//
+// If there is no panic, Recover does nothing, and *errp is unchanged.
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		var ctx context
+		*errp = ctx.recovered(r, callers())
+	}
+}
+
+// Recover recovers from a panic, and sets *errp to an error describing the
+// panic, with the context's key/value pairs attached. It must be called
+// directly by a deferred function call. See the Recover function.
+func (ctx context) Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = ctx.recovered(r, callers())
+	}
+}
+
+// recovered returns the error for a panic with value v.
+func (ctx context) recovered(v interface{}, st *stack) Error {
+	st = trimRuntime(st)
+	if err, ok := v.(error); ok {
+		return ctx.withKeyvals([]interface{}{"panic", true}).wrapError(err, panicMessage, st)
+	}
+	return ctx.withKeyvals([]interface{}{"panic", true, "value", v}).newError(panicMessage, st)
+}
+
+// trimRuntime removes the frames for the functions in the runtime package
+// that handle the panic from the start of the stack, so that the stack starts
+// at the function that panicked.
+func trimRuntime(st *stack) *stack {
+	s := *st
+	for len(s) > 0 {
+		fn := runtime.FuncForPC(s[0] - 1)
+		if fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+			break
+		}
+		s = s[1:]
+	}
+	return &s
+}
@@ -0,0 +1,62 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func panics(v interface{}) (err error) {
+	defer Recover(&err)
+	if v != nil {
+		panic(v)
+	}
+	return nil
+}
+
+func panicsWithContext(v interface{}) (err error) {
+	errors := With("id", 42)
+	defer errors.Recover(&err)
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	if err := panics(nil); err != nil {
+		t.Errorf("want nil, got %v", err)
+	}
+
+	err := panics("boom")
+	if got, want := err.Error(), "recovered from panic panic=true value=boom"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got, want := err.(keyvalser).Keyvals(), []interface{}{"msg", panicMessage, "panic", true, "value", "boom"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	trace := err.(interface{ StackTrace() StackTrace }).StackTrace()
+	if len(trace) == 0 {
+		t.Fatal("want stack trace")
+	}
+	if got, want := fmt.Sprintf("%n", trace[0]), "panics"; got != want {
+		t.Errorf("want stack trace to start at %q, got %q", want, got)
+	}
+
+	err = panicsWithContext(io.EOF)
+	if got, want := err.Error(), "recovered from panic id=42 panic=true: EOF"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if !Is(err, io.EOF) || Cause(err) != io.EOF {
+		t.Errorf("want cause to be io.EOF")
+	}
+
+	err = func() (err error) {
+		defer Recover(&err)
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	}()
+	if got := err.Error(); !strings.HasPrefix(got, "recovered from panic panic=true: assignment to entry in nil map") {
+		t.Errorf("unexpected error %q", got)
+	}
+}
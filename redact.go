@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"path"
+	"regexp"
+	"sync/atomic"
+)
+
+// DefaultMask is the text that replaces a redacted value
+// when a RedactPolicy does not specify a mask.
+const DefaultMask = "REDACTED"
+
+// A RedactPolicy specifies the key/value pairs whose values should be
+// masked whenever an error is rendered, including by the Error, Keyvals,
+// MarshalText, MarshalJSON and LogValue methods.
+//
+// A value is redacted if its key matches any of the keys, glob patterns
+// or regular expressions in the policy. Values created by Secret are always
+// redacted, regardless of policy.
+type RedactPolicy struct {
+	Keys    []string         // keys matched exactly
+	Globs   []string         // keys matched using path.Match
+	Regexps []*regexp.Regexp // keys matched by regular expression
+	Mask    string           // replaces redacted values, default is DefaultMask
+}
+
+// redacts reports whether the policy redacts values with the key.
+func (p *RedactPolicy) redacts(key interface{}) bool {
+	if p == nil {
+		return false
+	}
+	s, ok := key.(string)
+	if !ok {
+		return false
+	}
+	for _, k := range p.Keys {
+		if k == s {
+			return true
+		}
+	}
+	for _, pattern := range p.Globs {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+	for _, re := range p.Regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask returns the text that replaces a redacted value.
+func (p *RedactPolicy) mask() string {
+	if p == nil || p.Mask == "" {
+		return DefaultMask
+	}
+	return p.Mask
+}
+
+// redactPolicy is the package-level redaction policy.
+var redactPolicy atomic.Pointer[RedactPolicy]
+
+// SetRedactPolicy sets the redaction policy that applies to all errors.
+// A nil policy removes the package-level policy.
+//
+// A Context can specify an additional policy that applies to errors
+// created from that context: see the WithRedactPolicy method. A value
+// is redacted if either policy redacts it.
+func SetRedactPolicy(policy *RedactPolicy) {
+	redactPolicy.Store(policy)
+}
+
+// Secret returns a value that is always redacted when an error is
+// rendered. It is used to attach sensitive values to an error without
+// having to configure a redaction policy:
+//  return errors.New("cannot log in").With("user", user, "password", errors.Secret(password))
+func Secret(value interface{}) interface{} {
+	return secret{value: value}
+}
+
+// secret is a value that is always redacted.
+type secret struct {
+	value interface{}
+}
+
+// String implements fmt.Stringer, and does not reveal the value.
+func (s secret) String() string {
+	return DefaultMask
+}
+
+// MarshalText implements encoding.TextMarshaler, and does not reveal the value.
+func (s secret) MarshalText() ([]byte, error) {
+	return []byte(DefaultMask), nil
+}
+
+// redactValue returns the value for a key/value pair, after applying
+// the context's redaction policy and the package-level policy. Lazy
+// values are evaluated, unless they are redacted.
+func (ctx context) redactValue(key, value interface{}) interface{} {
+	if ctx.redact.redacts(key) {
+		return ctx.redact.mask()
+	}
+	policy := redactPolicy.Load()
+	if policy.redacts(key) {
+		return policy.mask()
+	}
+	if l, ok := value.(*lazy); ok {
+		value = l.value()
+	}
+	if _, ok := value.(secret); ok {
+		if ctx.redact != nil {
+			return ctx.redact.mask()
+		}
+		return policy.mask()
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	SetRedactPolicy(&RedactPolicy{
+		Keys:    []string{"password"},
+		Globs:   []string{"*_token"},
+		Regexps: []*regexp.Regexp{regexp.MustCompile(`(?i)^e-?mail$`)},
+	})
+	defer SetRedactPolicy(nil)
+
+	tests := []struct {
+		err     error
+		text    string
+		keyvals []interface{}
+		json    string
+	}{
+		{
+			err:     New("cannot log in").With("user", "bob", "password", "hunter2"),
+			text:    "cannot log in user=bob password=REDACTED",
+			keyvals: []interface{}{"msg", "cannot log in", "user", "bob", "password", "REDACTED"},
+			json:    `{"msg":"cannot log in","keyvals":{"user":"bob","password":"REDACTED"}}`,
+		},
+		{
+			err:     Wrap(New("expired").With("access_token", "abc"), "cannot refresh").With("Email", "bob@example.com"),
+			text:    "cannot refresh Email=REDACTED: expired access_token=REDACTED",
+			keyvals: []interface{}{"msg", "cannot refresh", "Email", "REDACTED", "cause.msg", "expired", "access_token", "REDACTED"},
+			json:    `{"msg":"cannot refresh","keyvals":{"Email":"REDACTED"},"cause":{"msg":"expired","keyvals":{"access_token":"REDACTED"}}}`,
+		},
+		{
+			err:     New("cannot sign").With("key", Secret("private"), "id", 1),
+			text:    "cannot sign key=REDACTED id=1",
+			keyvals: []interface{}{"msg", "cannot sign", "key", "REDACTED", "id", 1},
+			json:    `{"msg":"cannot sign","keyvals":{"key":"REDACTED","id":1}}`,
+		},
+		{
+			err:     With("pin", 1234, "id", 1).WithRedactPolicy(&RedactPolicy{Keys: []string{"pin"}, Mask: "xxx"}).New("invalid pin"),
+			text:    "invalid pin pin=xxx id=1",
+			keyvals: []interface{}{"msg", "invalid pin", "pin", "xxx", "id", 1},
+			json:    `{"msg":"invalid pin","keyvals":{"pin":"xxx","id":1}}`,
+		},
+		{
+			err:     With("pin", 1234).WithRedactPolicy(&RedactPolicy{Keys: []string{"pin"}, Mask: "xxx"}).New("invalid pin").With("password", "x", "secret", Secret("y")),
+			text:    "invalid pin pin=xxx password=REDACTED secret=xxx",
+			keyvals: []interface{}{"msg", "invalid pin", "pin", "xxx", "password", "REDACTED", "secret", "xxx"},
+			json:    `{"msg":"invalid pin","keyvals":{"pin":"xxx","password":"REDACTED","secret":"xxx"}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		if got := tt.err.Error(); got != tt.text {
+			t.Errorf("%d: Error: want %q, got %q", i, tt.text, got)
+		}
+		if got := tt.err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, tt.keyvals) {
+			t.Errorf("%d: Keyvals: want %v, got %v", i, tt.keyvals, got)
+		}
+		if got, _ := json.Marshal(tt.err); string(got) != withFingerprint(tt.json, tt.err) {
+			t.Errorf("%d: MarshalJSON: want %s, got %s", i, withFingerprint(tt.json, tt.err), got)
+		}
+		if got, _ := tt.err.(interface{ MarshalText() ([]byte, error) }).MarshalText(); string(got) != tt.text {
+			t.Errorf("%d: MarshalText: want %q, got %q", i, tt.text, got)
+		}
+		var buf bytes.Buffer
+		slog.New(slog.NewTextHandler(&buf, nil)).Error("failed", "err", tt.err)
+		for _, secret := range []string{"hunter2", "abc", "bob@example.com", "private", "1234"} {
+			if strings.Contains(buf.String(), secret) {
+				t.Errorf("%d: LogValue: found %q in %s", i, secret, buf.String())
+			}
+		}
+	}
+
+	ctx := With("password", "hunter2")
+	if got, want := ctx.(keyvalser).Keyvals(), []interface{}{"password", "REDACTED"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Context.Keyvals: want %v, got %v", want, got)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	// no policy required
+	err := New("cannot sign").With("key", Secret("private"))
+	if got, want := err.Error(), "cannot sign key=REDACTED"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got := Secret("private"); strings.Contains(fmt.Sprint(got), "private") {
+		t.Errorf("Secret: value revealed by %q", fmt.Sprint(got))
+	}
+}
@@ -0,0 +1,154 @@
+package errors
+
+import (
+	"time"
+)
+
+// Retryable reports whether the operation that failed with err is worth
+// retrying.
+//
+// An error is marked as retryable, or not retryable, by attaching a
+// key/value pair with the key "retryable" and a bool value:
+//  return errors.Wrap(err, "cannot send message").With("retryable", true)
+//
+// Retryable examines each error in the chain, starting with err, and
+// the first "retryable" value found is used. If no error in the chain
+// is marked, an error is retryable if it has a RetryAfter hint, if it
+// is temporary (see Temporary), or if it has the kind Unavailable.
+func Retryable(err error) bool {
+	var retryable, found bool
+	walk(err, func(err error) bool {
+		retryable, found = boolValue(err, "retryable")
+		return found
+	})
+	if found {
+		return retryable
+	}
+	if _, ok := RetryAfter(err); ok {
+		return true
+	}
+	return Temporary(err) || KindOf(err) == Unavailable
+}
+
+// Temporary reports whether err is the result of a temporary condition.
+//
+// An error is marked as temporary, or not temporary, by attaching a
+// key/value pair with the key "temporary" and a bool value:
+//  return errors.With("temporary", true).New("queue is full")
+//
+// Temporary examines each error in the chain, starting with err, and the
+// first "temporary" value found is used. An error from another package
+// is temporary if it has a Temporary or Timeout method that returns
+// true, as do the errors implementing net.Error.
+func Temporary(err error) bool {
+	var temporary bool
+	walk(err, func(err error) bool {
+		var found bool
+		if temporary, found = boolValue(err, "temporary"); found {
+			return true
+		}
+		if e, ok := err.(interface{ Temporary() bool }); ok && e.Temporary() {
+			temporary = true
+			return true
+		}
+		if e, ok := err.(interface{ Timeout() bool }); ok && e.Timeout() {
+			temporary = true
+			return true
+		}
+		return false
+	})
+	return temporary
+}
+
+// RetryAfter returns how long to wait before retrying the operation that
+// failed with err, and reports whether err has a retry hint.
+//
+// The hint is attached as a key/value pair with the key "retry_after" and
+// a time.Duration value:
+//  return errors.New("rate limit exceeded").With("retry_after", 5*time.Second)
+//
+// RetryAfter examines each error in the chain, starting with err,
+// and the first "retry_after" value found is used.
+func RetryAfter(err error) (time.Duration, bool) {
+	var d time.Duration
+	found := walk(err, func(err error) bool {
+		var ok bool
+		if v, found := layerValue(err, "retry_after"); found {
+			d, ok = v.(time.Duration)
+		}
+		return ok
+	})
+	return d, found
+}
+
+// walk calls fn for each error in the chain of err, starting with err
+// and following the Unwrap and Cause methods, until fn returns true.
+// For an error that aggregates several errors (see Join), each error
+// is walked in turn. It reports whether fn returned true.
+func walk(err error, fn func(err error) bool) bool {
+	type causer interface {
+		Cause() error
+	}
+
+	for err != nil {
+		if fn(err) {
+			return true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if walk(err, fn) {
+					return true
+				}
+			}
+			return false
+		case causer:
+			err = e.Cause()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// boolValue returns the bool value of the key/value pair attached to
+// err with key, and reports whether there is such a value.
+func boolValue(err error, key string) (value bool, ok bool) {
+	if v, found := layerValue(err, key); found {
+		value, ok = v.(bool)
+	}
+	return value, ok
+}
+
+// layerValue returns the value of the key/value pair attached to err
+// with key, without examining the cause of err.
+func layerValue(err error, key string) (interface{}, bool) {
+	type valuer interface {
+		value(key string) (interface{}, bool)
+	}
+	if v, ok := err.(valuer); ok {
+		return v.value(key)
+	}
+	return nil, false
+}
+
+// value returns the value of the last key/value pair in the
+// context with key, and reports whether there is such a pair.
+func (ctx context) value(key string) (interface{}, bool) {
+	for i := len(ctx.keyvals) - 2 - len(ctx.keyvals)%2; i >= 0; i -= 2 {
+		if ctx.keyvals[i] == key {
+			v := ctx.keyvals[i+1]
+			if l, ok := v.(*lazy); ok {
+				v = l.value()
+			}
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (e *errorT) value(key string) (interface{}, bool)  { return e.ctx.value(key) }
+func (a *attachT) value(key string) (interface{}, bool) { return a.ctx.value(key) }
+func (j *joinT) value(key string) (interface{}, bool)   { return j.ctx.value(key) }
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	stdcontext "context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// timeoutError implements net.Error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return false }
+
+var _ net.Error = timeoutError{}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err                  error
+		retryable, temporary bool
+	}{
+		{err: nil},
+		{err: io.EOF},
+		{err: New("file locked")},
+		{err: New("queue full").With("retryable", true), retryable: true},
+		{err: Wrap(New("queue full").With("retryable", true), "cannot send").With("retryable", false)},
+		{err: With("temporary", true).New("queue full"), retryable: true, temporary: true},
+		{err: Wrap(With("temporary", true).New("queue full")).With("temporary", false)},
+		{err: Wrap(timeoutError{}, "cannot read"), retryable: true, temporary: true},
+		{err: Wrap(fmt.Errorf("read: %w", timeoutError{}), "cannot read"), retryable: true, temporary: true},
+		{err: Wrap(stdcontext.DeadlineExceeded, "cannot read"), retryable: true, temporary: true},
+		{err: Wrap(timeoutError{}, "cannot read").With("retryable", false), temporary: true},
+		{err: New("rate limited").With("retry_after", time.Second), retryable: true},
+		{err: New("unavailable").With("kind", Unavailable), retryable: true},
+		{err: Join(io.EOF, New("queue full").With("temporary", true)), retryable: true, temporary: true},
+		{err: New("lazy").With("retryable", Lazy(func() interface{} { return true })), retryable: true},
+	}
+	for i, tt := range tests {
+		if got := Retryable(tt.err); got != tt.retryable {
+			t.Errorf("%d: %v: want retryable %v, got %v", i, tt.err, tt.retryable, got)
+		}
+		if got := Temporary(tt.err); got != tt.temporary {
+			t.Errorf("%d: %v: want temporary %v, got %v", i, tt.err, tt.temporary, got)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		err  error
+		want time.Duration
+		ok   bool
+	}{
+		{err: nil},
+		{err: New("rate limited")},
+		{err: New("rate limited").With("retry_after", "soon")},
+		{err: New("rate limited").With("retry_after", time.Second), want: time.Second, ok: true},
+		{
+			err:  Wrap(New("rate limited").With("retry_after", time.Second), "cannot send").With("retry_after", time.Minute),
+			want: time.Minute,
+			ok:   true,
+		},
+		{err: Wrap(New("rate limited").With("retry_after", time.Second), "cannot send"), want: time.Second, ok: true},
+	}
+	for i, tt := range tests {
+		got, ok := RetryAfter(tt.err)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%d: want %v, %v, got %v, %v", i, tt.want, tt.ok, got, ok)
+		}
+	}
+}
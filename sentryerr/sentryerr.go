@@ -0,0 +1,431 @@
+// Package sentryerr builds Sentry events from errors, and sends them to
+// a Sentry-compatible collector.
+//
+// Each wrapped error in the chain becomes an exception in the event, with
+// the innermost error first, as Sentry expects. Key/value pairs attached to
+// the error become the extra data of the event, except for the keys selected
+// using Options.Tags, which become tags. The stack trace recorded by each
+// error is included with its exception, and the event fingerprint is
+// derived from errors.Fingerprint, so that Sentry groups similar errors.
+package sentryerr
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jjeffery/errors"
+)
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// messager is implemented by the errors in package github.com/jjeffery/errors
+// that have a message.
+type messager interface {
+	Message() string
+}
+
+// stackTracer is implemented by the errors in package
+// github.com/jjeffery/errors that record a stack trace.
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// Event is a Sentry event. Only the fields used by this package
+// are represented.
+type Event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Platform    string                 `json:"platform"`
+	Logger      string                 `json:"logger,omitempty"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Message     string                 `json:"message,omitempty"`
+	Exception   *Exceptions            `json:"exception,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+}
+
+// Exceptions contains the exceptions of an event, innermost first.
+type Exceptions struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception describes one error in the chain.
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace contains stack frames, oldest first.
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a stack frame.
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Options control how an error is converted to an event.
+type Options struct {
+	// Tags lists the keys of the key/value pairs attached to the error
+	// that become tags of the event. All other key/value pairs become
+	// extra data. The kind of the error (see errors.KindOf) is always
+	// included as the tag "kind".
+	Tags []string
+
+	Logger      string // name of the logger, optional
+	ServerName  string // name of the host, optional
+	Release     string // release version of the program, optional
+	Environment string // environment name, such as "production", optional
+}
+
+// NewEvent returns the event for err, which must not be nil. If opts
+// is nil, the default options are used.
+func NewEvent(err error, opts *Options) *Event {
+	if opts == nil {
+		opts = &Options{}
+	}
+	event := &Event{
+		EventID:     newEventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       "error",
+		Platform:    "go",
+		Logger:      opts.Logger,
+		ServerName:  opts.ServerName,
+		Release:     opts.Release,
+		Environment: opts.Environment,
+		Message:     err.Error(),
+		Exception:   &Exceptions{Values: exceptions(err)},
+		Fingerprint: []string{errors.Fingerprint(err)},
+	}
+
+	if kind := errors.KindOf(err); kind != "" {
+		event.Tags = map[string]string{"kind": string(kind)}
+	}
+	// The key/value pairs of an error include those of its cause, unless
+	// the cause is wrapped by an error from another package.
+	covered := false
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		kv, ok := e.(keyvalser)
+		if ok && !covered {
+			event.addKeyvals(kv.Keyvals(), opts)
+		}
+		covered = ok && isPackageError(e)
+	}
+	return event
+}
+
+// addKeyvals adds key/value pairs to the tags and extra data of the
+// event. If a key occurs more than once, the last value is used, unless
+// the key has already been added from an outer error.
+func (event *Event) addKeyvals(keyvals []interface{}, opts *Options) {
+	for i := len(keyvals) - 2 - len(keyvals)%2; i >= 0; i -= 2 {
+		key := fmt.Sprint(keyvals[i])
+		if errors.IsMessageKey(key) {
+			continue
+		}
+		value := keyvals[i+1]
+		if contains(opts.Tags, key) {
+			if _, ok := event.Tags[key]; ok {
+				continue
+			}
+			if event.Tags == nil {
+				event.Tags = make(map[string]string)
+			}
+			event.Tags[key] = valueString(value)
+			continue
+		}
+		if _, ok := event.Extra[key]; ok {
+			continue
+		}
+		if event.Extra == nil {
+			event.Extra = make(map[string]interface{})
+		}
+		event.Extra[key] = extraValue(value)
+	}
+}
+
+// exceptions returns an exception for each error in the chain,
+// innermost first. Errors that attach key/value pairs to their
+// cause without a message do not have an exception of their own.
+func exceptions(err error) []Exception {
+	var values []Exception
+	for err != nil {
+		next := errors.Unwrap(err)
+		if m, ok := err.(messager); ok {
+			values = append(values, exception(err, m.Message()))
+		} else if next == nil || !isPackageError(err) {
+			values = append(values, exception(err, err.Error()))
+		}
+		err = next
+	}
+
+	// innermost first
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	return values
+}
+
+func exception(err error, value string) Exception {
+	return Exception{
+		Type:       reflect.TypeOf(err).String(),
+		Value:      value,
+		Stacktrace: stacktrace(err),
+	}
+}
+
+// isPackageError reports whether err is one of the errors in package
+// github.com/jjeffery/errors.
+func isPackageError(err error) bool {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() == "github.com/jjeffery/errors"
+}
+
+// stacktrace returns the stack trace recorded by err, if any.
+func stacktrace(err error) *Stacktrace {
+	st, ok := err.(stackTracer)
+	if !ok {
+		return nil
+	}
+	trace := st.StackTrace()
+	if len(trace) == 0 {
+		return nil
+	}
+	pcs := make([]uintptr, len(trace))
+	for i, f := range trace {
+		pcs[i] = uintptr(f)
+	}
+	var frames []Frame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		rf, more := callers.Next()
+		module, function := splitFunction(rf.Function)
+		frames = append(frames, Frame{
+			Function: function,
+			Module:   module,
+			Filename: shortFilename(rf.File),
+			AbsPath:  rf.File,
+			Lineno:   rf.Line,
+			InApp:    isInApp(module),
+		})
+		if !more {
+			break
+		}
+	}
+
+	// oldest first
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &Stacktrace{Frames: frames}
+}
+
+// splitFunction splits a qualified function name, such as
+// "github.com/jjeffery/errors.(*errorT).Error", into its
+// package path and function name.
+func splitFunction(name string) (module string, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// shortFilename returns the last directory and the name of a file.
+func shortFilename(file string) string {
+	parts := strings.Split(file, "/")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, "/")
+}
+
+// isInApp reports whether a package is part of the program, rather
+// than the standard library.
+func isInApp(module string) bool {
+	first := module
+	if i := strings.Index(first, "/"); i >= 0 {
+		first = first[:i]
+	}
+	return strings.Contains(first, ".") || module == "main"
+}
+
+// extraValue returns value if it can be represented in JSON,
+// otherwise its string representation.
+func extraValue(value interface{}) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = "PANIC"
+		}
+	}()
+	switch value.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	}
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return value
+}
+
+// valueString converts a value to a string for a tag.
+func valueString(value interface{}) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = "PANIC"
+		}
+	}()
+	return fmt.Sprint(value)
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// newEventID returns a random event ID: 32 hexadecimal digits.
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// A Transport sends events to a Sentry-compatible collector.
+type Transport interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+// HTTPTransport sends events to the store endpoint of a Sentry-compatible
+// collector using HTTP.
+type HTTPTransport struct {
+	// Client is the HTTP client used to send events. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+
+	url       string
+	publicKey string
+}
+
+// NewHTTPTransport returns a transport that sends events to the
+// project identified by dsn, which has the form
+// "https://public_key@host/project_id". The public key is not included
+// in the errors returned.
+func NewHTTPTransport(dsn string) (*HTTPTransport, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			// the message of a *url.Error contains the DSN
+			err = urlErr.Err
+		}
+		return nil, errors.Wrap(err, "invalid DSN")
+	}
+	errors := errors.With("dsn", redactDSN(u))
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("missing public key in DSN")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, errors.New("missing project ID in DSN")
+	}
+	return &HTTPTransport{
+		url:       fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:slash], projectID),
+		publicKey: u.User.Username(),
+	}, nil
+}
+
+// redactDSN returns the DSN with the public key replaced, so that
+// it can be attached to an error.
+func redactDSN(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User("REDACTED")
+	}
+	return redacted.String()
+}
+
+// Send implements the Transport interface.
+func (t *HTTPTransport) Send(ctx context.Context, event *Event) error {
+	errors := errors.With("event_id", event.EventID)
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal event")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "cannot create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf(
+		"Sentry sentry_version=7, sentry_client=jjeffery-errors/1.0, sentry_key=%s",
+		t.publicKey,
+	))
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cannot send event")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.With("status", resp.StatusCode).New("event rejected")
+	}
+	return nil
+}
+
+// Capture builds the event for err and sends it using transport, returning
+// the event ID. If err is nil, Capture does nothing and returns an empty
+// event ID.
+func Capture(ctx context.Context, transport Transport, err error, opts *Options) (string, error) {
+	if err == nil {
+		return "", nil
+	}
+	event := NewEvent(err, opts)
+	if sendErr := transport.Send(ctx, event); sendErr != nil {
+		return "", sendErr
+	}
+	return event.EventID, nil
+}
@@ -0,0 +1,147 @@
+package sentryerr_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/sentryerr"
+)
+
+func newError() error {
+	var err error = errors.New("file locked").With("file", "testrun", "kind", errors.Conflict)
+	err = fmt.Errorf("cannot open: %w", err)
+	err = errors.Wrap(err).With("user", "bob")
+	return errors.Wrap(err, "retry failed").With("attempt", 3)
+}
+
+func TestNewEvent(t *testing.T) {
+	err := newError()
+	event := sentryerr.NewEvent(err, &sentryerr.Options{
+		Tags:    []string{"user"},
+		Release: "v1.2.3",
+	})
+
+	if len(event.EventID) != 32 {
+		t.Errorf("unexpected event ID %q", event.EventID)
+	}
+	if event.Level != "error" || event.Platform != "go" || event.Release != "v1.2.3" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if got, want := event.Message, err.Error(); got != want {
+		t.Errorf("want message %q, got %q", want, got)
+	}
+	if got, want := event.Fingerprint, []string{errors.Fingerprint(err)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want fingerprint %v, got %v", want, got)
+	}
+	if got, want := event.Tags, map[string]string{"kind": "conflict", "user": "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want tags %v, got %v", want, got)
+	}
+	wantExtra := map[string]interface{}{"attempt": 3, "file": "testrun", "kind": "conflict"}
+	if got := event.Extra; !reflect.DeepEqual(got, wantExtra) {
+		t.Errorf("want extra %v, got %v", wantExtra, got)
+	}
+
+	var values []string
+	for _, ex := range event.Exception.Values {
+		values = append(values, ex.Type+": "+ex.Value)
+	}
+	wantValues := []string{
+		"*errors.errorT: file locked",
+		"*fmt.wrapError: cannot open: file locked file=testrun kind=conflict",
+		"*errors.causeT: retry failed",
+	}
+	if !reflect.DeepEqual(values, wantValues) {
+		t.Errorf("want exceptions %q, got %q", wantValues, values)
+	}
+
+	outer := event.Exception.Values[2]
+	if outer.Stacktrace == nil {
+		t.Fatal("want stack trace, got nil")
+	}
+	frames := outer.Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if last.Function != "newError" || last.Module != "github.com/jjeffery/errors/sentryerr_test" || !last.InApp {
+		t.Errorf("unexpected innermost frame %+v", last)
+	}
+	if !strings.HasSuffix(last.AbsPath, "sentryerr_test.go") || last.Lineno == 0 {
+		t.Errorf("unexpected innermost frame %+v", last)
+	}
+	if event.Exception.Values[1].Stacktrace != nil {
+		t.Errorf("want no stack trace for foreign error")
+	}
+
+	if _, err := json.Marshal(event); err != nil {
+		t.Errorf("cannot marshal event: %v", err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	var (
+		gotPath  string
+		gotAuth  string
+		gotEvent map[string]interface{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("X-Sentry-Auth")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &gotEvent); err != nil {
+			t.Errorf("cannot unmarshal event: %v", err)
+		}
+		if strings.Contains(string(body), "please reject") {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	transport, err := sentryerr.NewHTTPTransport(strings.Replace(srv.URL, "http://", "http://public@", 1) + "/sentry/42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventID, err := sentryerr.Capture(context.Background(), transport, newError(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/sentry/api/42/store/" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if !strings.Contains(gotAuth, "sentry_key=public") {
+		t.Errorf("unexpected auth header %q", gotAuth)
+	}
+	if gotEvent["event_id"] != eventID {
+		t.Errorf("want event ID %q, got %v", eventID, gotEvent["event_id"])
+	}
+
+	_, err = sentryerr.Capture(context.Background(), transport, errors.New("please reject"), nil)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if got, want := err.Error(), "status=429"; !strings.Contains(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	if eventID, err := sentryerr.Capture(context.Background(), transport, nil, nil); eventID != "" || err != nil {
+		t.Errorf("want no event for nil error, got %q, %v", eventID, err)
+	}
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	for _, dsn := range []string{"https://example.com/1", "https://key@example.com/", ":", "https://key@exa mple.com/1"} {
+		_, err := sentryerr.NewHTTPTransport(dsn)
+		if err == nil {
+			t.Errorf("%s: want error, got nil", dsn)
+			continue
+		}
+		if text := fmt.Sprintf("%+v", err); strings.Contains(text, "key@") {
+			t.Errorf("%s: want DSN redacted, got %s", dsn, text)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// slogAttrser is implemented by each of the error types in this package.
+type slogAttrser interface {
+	slogAttrs() []slog.Attr
+}
+
+// LogValue implements the slog.LogValuer interface. The error is
+// logged as a group containing its message and key/value pairs.
+func (e *errorT) LogValue() slog.Value {
+	return logValue(e)
+}
+
+// LogValue implements the slog.LogValuer interface. The error is
+// logged as a group containing its message, key/value pairs and
+// a nested group for the cause.
+func (c *causeT) LogValue() slog.Value {
+	return logValue(c)
+}
+
+// LogValue implements the slog.LogValuer interface. The error is
+// logged as a group containing its key/value pairs and a nested
+// group for the cause.
+func (a *attachT) LogValue() slog.Value {
+	return logValue(a)
+}
+
+// LogValue implements the slog.LogValuer interface. The error is
+// logged as a group containing its key/value pairs and a nested
+// group for each error, keyed by index.
+func (j *joinT) LogValue() slog.Value {
+	return logValue(j)
+}
+
+func (e *errorT) slogAttrs() []slog.Attr {
+	attrs := []slog.Attr{slog.String("msg", e.message())}
+	if e.ctx.isTemplate(e.msg) {
+		attrs = append(attrs, slog.String("msg.template", e.msg))
+	}
+	return e.ctx.appendAttrs(attrs)
+}
+
+func (c *causeT) slogAttrs() []slog.Attr {
+	attrs := c.errorT.slogAttrs()
+	return append(attrs, slog.Attr{Key: "cause", Value: logValue(c.cause)})
+}
+
+func (a *attachT) slogAttrs() []slog.Attr {
+	attrs := a.ctx.appendAttrs(nil)
+	return append(attrs, slog.Attr{Key: "cause", Value: logValue(a.cause)})
+}
+
+func (j *joinT) slogAttrs() []slog.Attr {
+	attrs := j.ctx.appendAttrs(nil)
+	var errs []slog.Attr
+	for i, err := range j.errs {
+		errs = append(errs, slog.Attr{Key: strconv.Itoa(i), Value: logValue(err)})
+	}
+	return append(attrs, slog.Attr{Key: "errors", Value: slog.GroupValue(errs...)})
+}
+
+// logValue returns a group value for err. An error from another
+// package is represented by a group containing its message.
+func logValue(err error) slog.Value {
+	if s, ok := err.(slogAttrser); ok {
+		return slog.GroupValue(s.slogAttrs()...)
+	}
+	return slog.GroupValue(slog.String("msg", err.Error()))
+}
+
+// appendAttrs appends the context's key/value pairs to attrs.
+func (ctx context) appendAttrs(attrs []slog.Attr) []slog.Attr {
+	keyvals := ctx.appendKeyvals(nil)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		attrs = append(attrs, slog.Any(keyString(keyvals[i]), value))
+	}
+	return attrs
+}
@@ -0,0 +1,65 @@
+package errors
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{
+			err:  New("error message").With("a", 1, "b", "two words"),
+			want: `{"err":{"msg":"error message","a":1,"b":"two words"}}`,
+		},
+		{
+			err: With("attempt", 3).Wrap(New("file locked").With("file", "testrun"), "retry failed"),
+			want: `{"err":{"msg":"retry failed","attempt":3,` +
+				`"cause":{"msg":"file locked","file":"testrun"}}}`,
+		},
+		{
+			err:  Wrap(io.EOF).With("c", 3),
+			want: `{"err":{"c":3,"cause":{"msg":"EOF"}}}`,
+		},
+		{
+			err:  Join(New("first"), io.EOF).With("op", "close"),
+			want: `{"err":{"op":"close","errors":{"0":{"msg":"first"},"1":{"msg":"EOF"}}}}`,
+		},
+		{
+			err:  With("id", 1).New("{id} locked"),
+			want: `{"err":{"msg":"1 locked","msg.template":"{id} locked","id":1}}`,
+		},
+		{
+			err:  New("odd").With("k"),
+			want: `{"err":{"msg":"odd","k":null}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		logger.Error("", slog.Any("err", tt.err))
+		if got := strings.TrimSpace(buf.String()); got != tt.want {
+			t.Errorf("%d: want %s, got %s", i, tt.want, got)
+		}
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Error("failed", "err", Wrap(New("file locked").With("file", "testrun"), "retry failed"))
+	if got, want := buf.String(), ` err.msg="retry failed" err.cause.msg="file locked" err.cause.file=testrun`; !strings.Contains(got, want) {
+		t.Errorf("want %q in %q", want, got)
+	}
+}
@@ -0,0 +1,230 @@
+// Package slogerr provides a log/slog handler that lifts the key/value pairs
+// attached to an error into the log record.
+//
+// A typical log call that reports an error looks like this:
+//  logger.Error("request failed", "err", err)
+//
+// Without this package the key/value pairs attached to err are logged inside
+// the "err" attribute. The handler returned by NewHandler replaces the "err"
+// attribute with the error message, and adds the key/value pairs attached to
+// the error as attributes of the record, so that they can be indexed in the same
+// way as any other attribute. This is the automated equivalent of the
+// logError function in the documentation for package errors.
+package slogerr
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/jjeffery/errors"
+)
+
+// keyvalser is implemented by the errors in package
+// github.com/jjeffery/errors.
+type keyvalser interface {
+	Keyvals() []interface{}
+}
+
+// Collision specifies what happens when a key lifted from an error is the
+// same as the key of another attribute in the log record.
+type Collision int
+
+// Collision policies.
+const (
+	// Overwrite replaces the other attribute with the key/value pair
+	// from the error.
+	Overwrite Collision = iota
+
+	// Skip discards the key/value pair from the error.
+	Skip
+
+	// Rename adds a numeric suffix to the key from the error, so
+	// that both values are logged.
+	Rename
+)
+
+// Options control how key/value pairs are lifted from errors.
+type Options struct {
+	// Prefix is prepended to each key lifted from an error.
+	Prefix string
+
+	// Group is the name of a group that contains the key/value pairs
+	// lifted from errors. If Group is empty, the key/value pairs are
+	// added at the top level of the record.
+	Group string
+
+	// Collision specifies what happens when a key lifted from an error
+	// is the same as the key of another attribute. Collisions are
+	// detected between attributes at the same level, including attributes
+	// added using WithAttrs since the last call to WithGroup.
+	Collision Collision
+}
+
+// Handler is a slog.Handler that lifts the key/value pairs attached to
+// errors into the log record, before passing the record to another handler.
+type Handler struct {
+	next  slog.Handler
+	opts  Options
+	attrs []slog.Attr // added by WithAttrs since the last call to WithGroup
+}
+
+// NewHandler returns a handler that lifts key/value pairs from errors
+// in each log record, and passes the record to next. If opts is nil,
+// the default options are used.
+func NewHandler(next slog.Handler, opts *Options) *Handler {
+	h := &Handler{next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled implements the slog.Handler interface.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// WithAttrs implements the slog.Handler interface. The attributes are
+// kept by the handler and added to each record, so that errors in the
+// attributes are lifted, and so that the collision policy can be applied
+// to them.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &Handler{
+		next:  h.next,
+		opts:  h.opts,
+		attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+// WithGroup implements the slog.Handler interface.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	next := h.next
+	if len(h.attrs) > 0 {
+		attrs, _ := h.lift(h.attrs)
+		next = next.WithAttrs(attrs)
+	}
+	return &Handler{
+		next: next.WithGroup(name),
+		opts: h.opts,
+	}
+}
+
+// Handle implements the slog.Handler interface. Each attribute
+// whose value is an error with key/value pairs is replaced with the
+// error message, and the key/value pairs are added to the record.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs, found := h.lift(attrs)
+	if !found && len(h.attrs) == 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	return h.next.Handle(ctx, nr)
+}
+
+// lift replaces each attribute in attrs whose value is an error
+// with key/value pairs with the error message, and adds the key/value
+// pairs other than the messages in the error chain, applying the options.
+// It reports whether any errors were found.
+func (h *Handler) lift(attrs []slog.Attr) ([]slog.Attr, bool) {
+	var result, lifted []slog.Attr
+	var found bool
+	for _, a := range attrs {
+		kv, ok := keyvalserOf(a.Value)
+		if !ok {
+			result = append(result, a)
+			continue
+		}
+		found = true
+		result = append(result, slog.String(a.Key, a.Value.Any().(error).Error()))
+		keyvals := kv.Keyvals()
+		for i := 0; i < len(keyvals); i += 2 {
+			key := keyString(keyvals[i])
+			if errors.IsMessageKey(key) {
+				// the messages are logged in place of the error
+				continue
+			}
+			var value interface{}
+			if i+1 < len(keyvals) {
+				value = keyvals[i+1]
+			}
+			lifted = append(lifted, slog.Any(h.opts.Prefix+key, value))
+		}
+	}
+	if !found {
+		return attrs, false
+	}
+
+	if h.opts.Group != "" {
+		lifted = h.resolve(nil, lifted)
+		result = append(result, slog.Attr{Key: h.opts.Group, Value: slog.GroupValue(lifted...)})
+	} else {
+		result = h.resolve(result, lifted)
+	}
+	return result, true
+}
+
+// resolve appends the lifted attributes to attrs, applying
+// the collision policy.
+func (h *Handler) resolve(attrs, lifted []slog.Attr) []slog.Attr {
+	index := make(map[string]int)
+	for i, a := range attrs {
+		index[a.Key] = i
+	}
+	exists := func(key string) bool {
+		_, ok := index[key]
+		return ok
+	}
+	for _, a := range lifted {
+		if exists(a.Key) {
+			switch h.opts.Collision {
+			case Skip:
+				continue
+			case Rename:
+				key := a.Key
+				for n := 2; exists(key); n++ {
+					key = a.Key + "_" + strconv.Itoa(n)
+				}
+				a.Key = key
+			default:
+				attrs[index[a.Key]] = a
+				continue
+			}
+		}
+		index[a.Key] = len(attrs)
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// keyvalserOf returns the value as a keyvalser, if it is an
+// error that implements the keyvalser interface.
+func keyvalserOf(v slog.Value) (keyvalser, bool) {
+	if v.Kind() != slog.KindAny && v.Kind() != slog.KindLogValuer {
+		return nil, false
+	}
+	if _, ok := v.Any().(error); !ok {
+		return nil, false
+	}
+	kv, ok := v.Any().(keyvalser)
+	return kv, ok
+}
+
+// keyString returns the string representation of a key.
+func keyString(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return slog.AnyValue(key).String()
+}
@@ -0,0 +1,113 @@
+package slogerr_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/jjeffery/errors"
+	"github.com/jjeffery/errors/slogerr"
+)
+
+func TestHandler(t *testing.T) {
+	err := errors.With("file", "testrun").Wrap(io.EOF, "cannot read").With("attempt", 3)
+
+	tests := []struct {
+		opts  *slogerr.Options
+		attrs []slog.Attr
+		args  []interface{}
+		want  string
+	}{
+		{
+			args: []interface{}{"err", err},
+			want: `{"msg":"failed","err":"cannot read file=testrun attempt=3: EOF","file":"testrun","attempt":3}`,
+		},
+		{
+			opts: &slogerr.Options{Prefix: "err."},
+			args: []interface{}{"err", err},
+			want: `{"msg":"failed","err":"cannot read file=testrun attempt=3: EOF","err.file":"testrun","err.attempt":3}`,
+		},
+		{
+			opts: &slogerr.Options{Group: "error"},
+			args: []interface{}{"file", "other", "err", err},
+			want: `{"msg":"failed","file":"other","err":"cannot read file=testrun attempt=3: EOF","error":{"file":"testrun","attempt":3}}`,
+		},
+		{
+			args: []interface{}{"file", "other", "err", err},
+			want: `{"msg":"failed","file":"testrun","err":"cannot read file=testrun attempt=3: EOF","attempt":3}`,
+		},
+		{
+			opts: &slogerr.Options{Collision: slogerr.Skip},
+			args: []interface{}{"file", "other", "err", err},
+			want: `{"msg":"failed","file":"other","err":"cannot read file=testrun attempt=3: EOF","attempt":3}`,
+		},
+		{
+			opts: &slogerr.Options{Collision: slogerr.Rename},
+			args: []interface{}{"file", "other", "err", err},
+			want: `{"msg":"failed","file":"other","err":"cannot read file=testrun attempt=3: EOF","file_2":"testrun","attempt":3}`,
+		},
+		{
+			opts:  &slogerr.Options{Collision: slogerr.Rename},
+			attrs: []slog.Attr{slog.String("attempt", "first")},
+			args:  []interface{}{"err", err},
+			want:  `{"msg":"failed","attempt":"first","err":"cannot read file=testrun attempt=3: EOF","file":"testrun","attempt_2":3}`,
+		},
+		{
+			attrs: []slog.Attr{slog.String("attempt", "first")},
+			args:  []interface{}{"err", err},
+			want:  `{"msg":"failed","attempt":3,"err":"cannot read file=testrun attempt=3: EOF","file":"testrun"}`,
+		},
+		{
+			attrs: []slog.Attr{slog.Any("err", err)},
+			args:  []interface{}{"n", 1},
+			want:  `{"msg":"failed","err":"cannot read file=testrun attempt=3: EOF","n":1,"file":"testrun","attempt":3}`,
+		},
+		{
+			args: []interface{}{"err", errors.Wrap(errors.With("id", 1).New("{id} locked"), "retry failed")},
+			want: `{"msg":"failed","err":"retry failed: 1 locked id=1","id":1}`,
+		},
+		{
+			args: []interface{}{"err", errors.Join(errors.New("file locked").With("worker", 0), io.EOF)},
+			want: `{"msg":"failed","err":"file locked worker=0; EOF","error.0.msg":"file locked","error.0.worker":0,"error.1.msg":"EOF"}`,
+		},
+		{
+			args: []interface{}{"err", io.EOF, "n", 1},
+			want: `{"msg":"failed","err":"EOF","n":1}`,
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		h := slogerr.NewHandler(newJSONHandler(&buf), tt.opts).WithAttrs(tt.attrs)
+		slog.New(h).Error("failed", tt.args...)
+		if got := strings.TrimSpace(buf.String()); got != tt.want {
+			t.Errorf("%d:\nwant %s\ngot  %s", i, tt.want, got)
+		}
+	}
+}
+
+func TestHandlerWithGroup(t *testing.T) {
+	err := errors.New("cannot read").With("file", "testrun")
+
+	var buf bytes.Buffer
+	logger := slog.New(slogerr.NewHandler(newJSONHandler(&buf), nil))
+	logger.With("err", err).WithGroup("request").Error("failed", "err", err)
+
+	want := `{"msg":"failed","err":"cannot read file=testrun","file":"testrun","request":{"err":"cannot read file=testrun","file":"testrun"}}`
+	if got := strings.TrimSpace(buf.String()); got != want {
+		t.Errorf("\nwant %s\ngot  %s", want, got)
+	}
+}
+
+func newJSONHandler(buf *bytes.Buffer) slog.Handler {
+	return slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
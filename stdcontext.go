@@ -0,0 +1,93 @@
+package errors
+
+import (
+	stdcontext "context"
+	"sync"
+)
+
+// contextKey is the key for the error context carried by a context.Context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries an error context with
+// the key/value pairs. If ctx already carries an error context, the
+// key/value pairs are appended to it.
+//
+// NewContext is typically used to attach request-scoped key/value pairs,
+// such as a request ID, so that they are attached to errors created in
+// any function that has access to the context.Context:
+//  ctx = errors.NewContext(ctx, "request_id", requestID)
+//
+//  // ... later, in another function ...
+//
+//  errors := errors.FromContext(ctx)
+//  return errors.Wrap(err, "cannot get document")
+func NewContext(ctx stdcontext.Context, keyvals ...interface{}) stdcontext.Context {
+	errctx, _ := ctx.Value(contextKey{}).(context)
+	return stdcontext.WithValue(ctx, contextKey{}, errctx.withKeyvals(keyvals))
+}
+
+// FromContext returns an error context with the key/value pairs carried
+// by ctx (see NewContext), followed by the key/value pairs returned by
+// each of the registered extractors (see RegisterExtractor). Errors created
+// from the error context have these key/value pairs attached.
+func FromContext(ctx stdcontext.Context) Context {
+	errctx, _ := ctx.Value(contextKey{}).(context)
+	for _, extract := range registeredExtractors() {
+		if keyvals := extract(ctx); len(keyvals) > 0 {
+			errctx = errctx.withKeyvals(keyvals)
+		}
+	}
+	return errctx
+}
+
+// An Extractor returns key/value pairs from a context.Context. Extractors
+// are used to obtain values that are carried by a context.Context for other
+// purposes, such as tracing span IDs, so that they are attached to errors.
+type Extractor func(ctx stdcontext.Context) []interface{}
+
+// extractors contains the registered extractors.
+var extractors struct {
+	mu   sync.RWMutex
+	list []Extractor
+}
+
+// RegisterExtractor registers an extractor that is called by FromContext.
+// Extractors are called in the order they are registered. RegisterExtractor
+// is typically called during program initialization.
+func RegisterExtractor(extract Extractor) {
+	if extract == nil {
+		return
+	}
+	extractors.mu.Lock()
+	extractors.list = append(extractors.list, extract)
+	extractors.mu.Unlock()
+}
+
+// registeredExtractors returns the registered extractors.
+func registeredExtractors() []Extractor {
+	extractors.mu.RLock()
+	defer extractors.mu.RUnlock()
+	return extractors.list
+}
+
+// setExtractors replaces the registered extractors with list, and
+// returns the extractors that were registered. It is used by tests
+// to remove the extractors that they register.
+func setExtractors(list []Extractor) []Extractor {
+	extractors.mu.Lock()
+	defer extractors.mu.Unlock()
+	prev := extractors.list
+	extractors.list = list
+	return prev
+}
+
+// ExtractDeadline is an Extractor that returns the deadline of the
+// context.Context with the key "deadline", if it has a deadline.
+// It is not registered by default:
+//  errors.RegisterExtractor(errors.ExtractDeadline)
+func ExtractDeadline(ctx stdcontext.Context) []interface{} {
+	if deadline, ok := ctx.Deadline(); ok {
+		return []interface{}{"deadline", deadline}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package errors
+
+import (
+	stdcontext "context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testTraceKey struct{}
+
+func TestFromContext(t *testing.T) {
+	prev := setExtractors(nil)
+	t.Cleanup(func() { setExtractors(prev) })
+	RegisterExtractor(func(ctx stdcontext.Context) []interface{} {
+		if traceID, ok := ctx.Value(testTraceKey{}).(string); ok {
+			return []interface{}{"trace_id", traceID}
+		}
+		return nil
+	})
+	RegisterExtractor(ExtractDeadline)
+
+	ctx := stdcontext.Background()
+	if got := FromContext(ctx).New("message").Error(); got != "message" {
+		t.Errorf("want %q, got %q", "message", got)
+	}
+
+	ctx = NewContext(ctx, "request_id", "r1")
+	ctx = NewContext(ctx, "user", "bob")
+	ctx = stdcontext.WithValue(ctx, testTraceKey{}, "t1")
+
+	err := FromContext(ctx).With("id", 42).New("message")
+	want := []interface{}{"msg", "message", "request_id", "r1", "user", "bob", "trace_id", "t1", "id", 42}
+	if got := err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	deadline := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx, cancel := stdcontext.WithDeadline(NewContext(stdcontext.Background(), "k", "v"), deadline)
+	defer cancel()
+	err = FromContext(ctx).Wrap(stdcontext.Canceled, "message")
+	want = []interface{}{"msg", "message", "k", "v", "deadline", deadline, "cause.msg", "context canceled"}
+	if got := err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// message returns the message of the error, with any placeholders
+// in the message template replaced by the values of the error's
+// key/value pairs.
+func (e *errorT) message() string {
+	return e.ctx.expand(e.msg)
+}
+
+// isTemplate reports whether msg contains at least one placeholder
+// whose key matches the key of one of the context's key/value pairs.
+func (ctx context) isTemplate(msg string) bool {
+	for i := strings.IndexByte(msg, '{'); i >= 0; i = strings.IndexByte(msg, '{') {
+		if key, n := placeholder(msg[i:]); n > 0 && ctx.hasKey(key) {
+			return true
+		}
+		msg = msg[i+1:]
+	}
+	return false
+}
+
+// hasKey reports whether the context has a key/value pair with key.
+func (ctx context) hasKey(key string) bool {
+	for i := 0; i+1 < len(ctx.keyvals); i += 2 {
+		if ctx.keyvals[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder returns the key and length of the placeholder at the
+// start of s, or zero length if s does not start with a placeholder.
+// A placeholder is a key enclosed in braces, such as "{file}". The key
+// contains letters, digits and the characters '_', '.' and '-'.
+func placeholder(s string) (key string, n int) {
+	if len(s) < 3 || s[0] != '{' {
+		return "", 0
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '}':
+			if i == 1 {
+				return "", 0
+			}
+			return s[1:i], i + 1
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			c == '_', c == '.', c == '-':
+		default:
+			return "", 0
+		}
+	}
+	return "", 0
+}
+
+// expand replaces each placeholder in msg with the value of the
+// context's key/value pair with the same key. If there is more
+// than one key/value pair with the key, the last one is used.
+// Values are redacted in the same way as the key/value pairs.
+// A placeholder without a matching key is left unchanged, so that
+// a message that happens to contain braces is not altered.
+func (ctx context) expand(msg string) string {
+	if !ctx.isTemplate(msg) {
+		return msg
+	}
+	keyvals := ctx.appendKeyvals(nil)
+	var sb strings.Builder
+	for len(msg) > 0 {
+		i := strings.IndexByte(msg, '{')
+		if i < 0 {
+			sb.WriteString(msg)
+			break
+		}
+		sb.WriteString(msg[:i])
+		msg = msg[i:]
+		key, n := placeholder(msg)
+		if n == 0 {
+			sb.WriteByte('{')
+			msg = msg[1:]
+			continue
+		}
+		if value, ok := lookupKeyval(keyvals, key); ok {
+			sb.WriteString(valueString(value))
+		} else {
+			sb.WriteString(msg[:n])
+		}
+		msg = msg[n:]
+	}
+	return sb.String()
+}
+
+// lookupKeyval returns the value of the last key/value pair with key.
+func lookupKeyval(keyvals []interface{}, key string) (interface{}, bool) {
+	for i := len(keyvals) - 2 - len(keyvals)%2; i >= 0; i -= 2 {
+		if keyvals[i] == key {
+			return keyvals[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// valueString returns the string representation of a value in a message.
+func valueString(value interface{}) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = "PANIC"
+		}
+	}()
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{
+			err:  With("file", "config").New("cannot open {file}"),
+			want: "cannot open config file=config",
+		},
+		{
+			err:  New("cannot open {file}").With("file", "config"),
+			want: "cannot open config file=config",
+		},
+		{
+			err:  New("cannot open {file}"),
+			want: "cannot open {file}",
+		},
+		{
+			err:  New("cannot open {file} in {dir}").With("dir", "etc"),
+			want: "cannot open {file} in etc dir=etc",
+		},
+		{
+			err:  New("route /users/{id:MISSING}").With("id", 1),
+			want: "route /users/{id:MISSING} id=1",
+		},
+		{
+			err:  With("n", 1).New("{n} of {n}").With("n", 2),
+			want: "2 of 2 n=1 n=2",
+		},
+		{
+			err:  New("not {a template} {} {"),
+			want: "not {a template} {} {",
+		},
+		{
+			err:  Wrap(With("id", 1).New("{id} locked"), "retry {attempt}").With("attempt", 3),
+			want: "retry 3 attempt=3: 1 locked id=1",
+		},
+		{
+			err:  With().WithRedactPolicy(&RedactPolicy{Keys: []string{"user"}, Mask: "xxx"}).New("no user {user}").With("user", "bob"),
+			want: "no user xxx user=xxx",
+		},
+	}
+	for i, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%d: want %q, got %q", i, tt.want, got)
+		}
+	}
+}
+
+func TestTemplateKeyvals(t *testing.T) {
+	inner := With("id", 1).New("{id} locked")
+	err := Wrap(inner, "retry {attempt}").With("attempt", 3)
+	want := []interface{}{
+		"msg", "retry 3",
+		"msg.template", "retry {attempt}",
+		"attempt", 3,
+		"cause.msg", "1 locked",
+		"cause.msg.template", "{id} locked",
+		"id", 1,
+	}
+	if got := err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	literal := New("route /users/{id}").With("n", 1)
+	want = []interface{}{"msg", "route /users/{id}", "n", 1}
+	if got := literal.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	attached := Wrap(inner).With("n", 2)
+	want = []interface{}{
+		"msg", "1 locked",
+		"msg.template", "{id} locked",
+		"n", 2,
+		"id", 1,
+	}
+	if got := attached.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestTemplateJSON(t *testing.T) {
+	err := Wrap(With("id", 1).New("{id} locked"), "retry failed")
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	want := withFingerprint(`{"msg":"retry failed","cause":{"msg":"1 locked","msg.template":"{id} locked","keyvals":{"id":1}}}`, err)
+	if got := string(data); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+
+	decoded, decodeErr := UnmarshalJSON(data)
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if got, want := decoded.Error(), err.Error(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if got, want := decoded.(keyvalser).Keyvals(), err.(keyvalser).Keyvals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,225 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sync"
+)
+
+// registry contains the errors that can be restored by UnmarshalJSON,
+// keyed by message.
+var registry = struct {
+	mu   sync.RWMutex
+	errs map[string]error
+}{
+	errs: make(map[string]error),
+}
+
+// Register records a sentinel error so that it can be restored by
+// UnmarshalJSON. When UnmarshalJSON decodes an error without a cause
+// whose message matches a registered error, the result is derived
+// from the registered error, so that the Is function reports a match.
+//
+// Register is typically called during program initialization in both
+// the process that marshals the error and the process that unmarshals it:
+//  var ErrNotFound = errors.New("not found")
+//
+//  func init() {
+//      errors.Register(ErrNotFound)
+//  }
+//
+// Registering a second error with the same message replaces the first.
+func Register(err error) {
+	if err == nil {
+		return
+	}
+	registry.mu.Lock()
+	registry.errs[registeredMessage(err)] = err
+	registry.mu.Unlock()
+}
+
+// registeredMessage returns the message used to register err.
+func registeredMessage(err error) string {
+	if e, ok := err.(*errorT); ok {
+		return e.msg
+	}
+	return err.Error()
+}
+
+// lookupRegistered returns the registered error with the message, if any.
+func lookupRegistered(msg string) (error, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	err, ok := registry.errs[msg]
+	return err, ok
+}
+
+// UnmarshalJSON reconstructs an error from the JSON produced by the
+// MarshalJSON method of an error created by this package. The error
+// returned has the same messages, key/value pairs and layering as the
+// original error, but it does not have a stack trace. It has the same
+// fingerprint as the original error. See Fingerprint.
+//
+// Numbers in the key/value pairs are restored as int if they are
+// integers that fit, otherwise as float64. A string value with the
+// key "kind" is restored as a Kind. JSON objects and arrays are
+// restored as map[string]interface{} and []interface{} respectively.
+//
+// Errors without a cause are restored from the registered sentinel
+// errors, if possible. See Register.
+func UnmarshalJSON(data []byte) (Error, error) {
+	err, decodeErr := decodeJSONError(data)
+	if decodeErr != nil {
+		return nil, Wrap(decodeErr, "cannot unmarshal error")
+	}
+	if e, ok := err.(Error); ok {
+		return e, nil
+	}
+	// registered sentinel from another package
+	return &errorT{
+		msg:  err.Error(),
+		orig: err,
+	}, nil
+}
+
+// jsonError is the JSON representation of any error.
+type jsonError struct {
+	Msg         *string           `json:"msg"`
+	Template    *string           `json:"msg.template"`
+	Keyvals     json.RawMessage   `json:"keyvals"`
+	Cause       json.RawMessage   `json:"cause"`
+	Errors      []json.RawMessage `json:"errors"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// decodeJSONError decodes one layer of an error, and its causes.
+func decodeJSONError(data []byte) (error, error) {
+	var je jsonError
+	if err := json.Unmarshal(data, &je); err != nil {
+		return nil, err
+	}
+	keyvals, err := decodeJSONKeyvals(je.Keyvals)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context{keyvals: keyvals}
+	if je.Template != nil && je.Msg != nil {
+		// the message is rendered from the template and key/value pairs
+		je.Msg = je.Template
+	}
+
+	if je.Errors != nil {
+		var errs []error
+		for _, data := range je.Errors {
+			e, err := decodeJSONError(data)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, e)
+		}
+		return &joinT{ctx: ctx, errs: errs, fingerprint: je.Fingerprint}, nil
+	}
+
+	var cause error
+	if len(je.Cause) > 0 {
+		if cause, err = decodeJSONError(je.Cause); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case je.Msg != nil && cause != nil:
+		c := ctx.wrapError(cause, *je.Msg, nil)
+		c.fingerprint = je.Fingerprint
+		return c, nil
+	case je.Msg != nil:
+		if orig, ok := lookupRegistered(*je.Msg); ok {
+			if len(keyvals) == 0 {
+				return orig, nil
+			}
+			return &errorT{ctx: ctx, msg: *je.Msg, orig: orig, fingerprint: je.Fingerprint}, nil
+		}
+		e := ctx.newError(*je.Msg, nil)
+		e.fingerprint = je.Fingerprint
+		return e, nil
+	case cause != nil:
+		return &attachT{ctx: ctx, cause: cause, fingerprint: je.Fingerprint}, nil
+	}
+	return nil, New("missing msg, cause or errors")
+}
+
+// decodeJSONKeyvals decodes a JSON object into key/value pairs,
+// preserving the order of the fields.
+func decodeJSONKeyvals(data []byte) ([]interface{}, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, New("keyvals is not an object")
+	}
+	var keyvals []interface{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if s, ok := value.(string); ok && tok == "kind" {
+			// restore the kind, see KindOf
+			value = Kind(s)
+		}
+		keyvals = append(keyvals, tok, jsonNumbers(value))
+	}
+	return keyvals, nil
+}
+
+// UnmarshalJSONValue decodes a single JSON value in the same way as
+// UnmarshalJSON restores the values of key/value pairs: numbers are
+// restored as int if they are integers that fit, otherwise as float64,
+// and JSON objects and arrays are restored as map[string]interface{}
+// and []interface{} respectively. It returns an error if data is not
+// a single JSON value.
+func UnmarshalJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, New("unexpected data after JSON value")
+	}
+	return jsonNumbers(value), nil
+}
+
+// jsonNumbers replaces json.Number values with int or float64.
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil && n >= math.MinInt && n <= math.MaxInt {
+			return int(n)
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = jsonNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = jsonNumbers(value)
+		}
+	}
+	return v
+}